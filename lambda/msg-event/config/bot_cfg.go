@@ -2,36 +2,115 @@ package config
 
 import (
 	"msg-event/model"
+	"sync/atomic"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/sirupsen/logrus"
 )
 
-var Conf *Config
+// current is replaced as a whole on reload, so a request keeps reading the
+// config it got while a newer one is stored.
+var current atomic.Pointer[Config]
+
+// Get returns the loaded config, nil before the first load.
+func Get() *Config {
+	return current.Load()
+}
+
+// Set replaces the loaded config.
+func Set(c *Config) {
+	current.Store(c)
+}
 
 type Config struct {
 	Key              string              `dynamodbav:"key"`
-	SevMap           map[string]string   `dynamodbav:"sev_map"`
-	ServiceMap       map[string][]string `dynamodbav:"service_map"`
 	Usage            string              `dynamodbav:"usage"`
 	Accounts         map[string]*Account `dynamodbav:"accounts"`
 	AppID            string              `dynamodbav:"app_id"`
 	AppSecret        string              `dynamodbav:"app_secret"`
 	AppIDARN         string              `dynamodbav:"app_id_arn"`
 	AppSecretARN     string              `dynamodbav:"app_secret_arn"`
+	EncryptKeyARN    string              `dynamodbav:"encrypt_key_arn"`
+	VerifyTokenARN   string              `dynamodbav:"verification_token_arn"`
 	ErrCardTemplate  *model.FeiShuMsg    `dynamodbav:"err_card_template"`
 	CaseCardTemplate *model.FeiShuMsg    `dynamodbav:"case_card_template"`
 	Ack              string              `dynamodbav:"ack"`
 	NoPermissionMSG  string              `dynamodbav:"no_permission_msg"`
 	UserWhiteListMap map[string]string   `dynamodbav:"user_whitelist"`
-	RoleMap          map[string]string   `dynamodbav:"role"`
+	// DepartmentWhiteList (open_department_id, sub-departments included) and
+	// ChatWhiteList (chat_id) whitelist all their members
+	DepartmentWhiteList map[string]string `dynamodbav:"department_whitelist"`
+	ChatWhiteList       map[string]string `dynamodbav:"chat_whitelist"`
+	RoleMap             map[string]string `dynamodbav:"role"`
+	UserRoles           map[string]Role   `dynamodbav:"user_roles"`
+	// DefaultRole is given to whitelisted users without a role, or to everyone
+	// when the whitelist is disabled
+	DefaultRole   Role           `dynamodbav:"default_role"`
+	AccountSync   *AccountSync   `dynamodbav:"account_sync"`
+	AccountPolicy *AccountPolicy `dynamodbav:"account_policy"`
+	// DefaultLocale is the locale of users and chats without one, zh-CN when
+	// empty. ChatLocales and UserLocales are set with the 界面语言 command.
+	DefaultLocale string            `dynamodbav:"default_locale"`
+	ChatLocales   map[string]string `dynamodbav:"chat_locales"`
+	UserLocales   map[string]string `dynamodbav:"user_locales"`
+	// Messages override the messages of the catalog by locale and key
+	Messages map[string]map[string]string `dynamodbav:"messages"`
+	// CaseCardTemplates are the case card templates by locale, the other
+	// locales use CaseCardTemplate
+	CaseCardTemplates map[string]*model.FeiShuMsg `dynamodbav:"case_card_templates"`
+}
+
+// AllAccounts in an AccountPolicy grants every configured account.
+const AllAccounts = "*"
+
+// AccountPolicy maps users and Lark departments (open_department_id) to the
+// account keys they may open cases against. Without a policy every user may
+// use every account.
+type AccountPolicy struct {
+	Users       map[string][]string `dynamodbav:"users"`
+	Departments map[string][]string `dynamodbav:"departments"`
+	// Default is granted to everyone
+	Default []string `dynamodbav:"default"`
+}
+
+// AccountSync discovers Accounts from AWS Organizations.
+type AccountSync struct {
+	Enabled bool `dynamodbav:"enabled"`
+	// RolePattern builds the role arn of every account, {partition} and
+	// {account_id} are replaced, a plain role name is also accepted
+	RolePattern string `dynamodbav:"role_pattern"`
+	// ManagementRoleARN is assumed to list the accounts when the lambda does
+	// not run in the management or delegated admin account
+	ManagementRoleARN string `dynamodbav:"management_role_arn"`
+	Partition         string `dynamodbav:"partition"`
+	// ReportChatID receives the accounts whose role can not be assumed
+	ReportChatID string `dynamodbav:"report_chat_id"`
 }
 
 type Account struct {
 	AccessKeyID     string `dynamodbav:"access_key_id"`
 	SecretAccessKey string `dynamodbav:"secret_access_key"`
 	RoleARN         string `dynamodbav:"role_arn"`
+	Name            string `dynamodbav:"name"`
+	ExternalID      string `dynamodbav:"external_id"`
+	SessionName     string `dynamodbav:"session_name"`
+	// SessionTags are passed to AssumeRole as session tags
+	SessionTags map[string]string `dynamodbav:"session_tags"`
+	// Partition is aws or aws-cn, taken from RoleARN when empty
+	Partition string `dynamodbav:"partition"`
+	// Region of the Support endpoint, derived from Partition when empty
+	Region string `dynamodbav:"region"`
+	// Discovered accounts are managed by AccountSync and removed with the
+	// organization account
+	Discovered bool `dynamodbav:"discovered"`
+	// Unassumable is set when the sync could not assume the role, only new
+	// and unassumable accounts are checked again
+	Unassumable bool `dynamodbav:"unassumable"`
+	// ApprovalSeverity and above need an approval before the case is opened
+	ApprovalSeverity string `dynamodbav:"approval_severity"`
+	// Approvers are user ids, users with the approver role when empty
+	Approvers []string `dynamodbav:"approvers"`
 }
 
 // GetKey returns the primary key of the cfg in a format that can be
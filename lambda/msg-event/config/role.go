@@ -0,0 +1,33 @@
+package config
+
+// Role grants the commands of its own level and of every level below it.
+type Role string
+
+const (
+	RoleNone      Role = ""
+	RoleViewer    Role = "viewer"
+	RoleSubmitter Role = "submitter"
+	RoleApprover  Role = "approver"
+	RoleAdmin     Role = "admin"
+)
+
+var roleLevel = map[Role]int{
+	RoleNone:      0,
+	RoleViewer:    1,
+	RoleSubmitter: 2,
+	RoleApprover:  3,
+	RoleAdmin:     4,
+}
+
+// Roles lists the roles from the lowest, their names are in the i18n bundles.
+var Roles = []Role{RoleNone, RoleViewer, RoleSubmitter, RoleApprover, RoleAdmin}
+
+// Covers reports whether r may run commands requiring the role required.
+func (r Role) Covers(required Role) bool {
+	return roleLevel[r] >= roleLevel[required]
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleLevel[r]
+	return ok && r != RoleNone
+}
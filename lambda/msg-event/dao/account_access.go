@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"msg-event/config"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+var ErrAccountDenied = errors.New("account is not allowed for user")
+
+// AllowedAccounts returns the configured account keys the user may use,
+// sorted. The departments of the user are only looked up when the policy
+// grants accounts to departments.
+func AllowedAccounts(userID string) ([]string, error) {
+	conf := config.Get()
+	p := conf.AccountPolicy
+	grants := map[string]bool{}
+	if p == nil {
+		grants[config.AllAccounts] = true
+	} else {
+		for _, k := range p.Default {
+			grants[k] = true
+		}
+		for _, k := range p.Users[userID] {
+			grants[k] = true
+		}
+		if len(p.Departments) > 0 && !grants[config.AllAccounts] {
+			departments, err := GetUserDepartments(userID)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range departments {
+				for _, k := range p.Departments[d] {
+					grants[k] = true
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(conf.Accounts))
+	for k := range conf.Accounts {
+		if grants[config.AllAccounts] || grants[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// CheckAccountAccess returns ErrAccountDenied, wrapped in an AccountError,
+// when the user may not use the account.
+func CheckAccountAccess(userID, key string) error {
+	if _, err := getAccount(key); err != nil {
+		return err
+	}
+	keys, err := AllowedAccounts(userID)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k == key {
+			return nil
+		}
+	}
+	return &AccountError{AccountKey: key, Err: ErrAccountDenied}
+}
+
+// SetUserAccounts grants each user exactly the given account keys. Without an
+// account policy a new one is created that keeps granting every account to
+// the other users.
+func SetUserAccounts(users map[string][]string) error {
+	if len(users) == 0 {
+		return nil
+	}
+	p := config.Get().AccountPolicy
+	attrNames := map[string]string{"#Policy": "account_policy"}
+	attrValues := map[string]types.AttributeValue{}
+	var setParts []string
+
+	switch {
+	case p == nil:
+		// a nested path can only be set on an existing map
+		policy, err := attributevalue.MarshalMap(&config.AccountPolicy{
+			Users:   users,
+			Default: []string{config.AllAccounts},
+		})
+		if err != nil {
+			return err
+		}
+		setParts = append(setParts, "#Policy = :policy")
+		attrValues[":policy"] = &types.AttributeValueMemberM{Value: policy}
+	case p.Users == nil:
+		value, err := attributevalue.Marshal(users)
+		if err != nil {
+			return err
+		}
+		attrNames["#Users"] = "users"
+		setParts = append(setParts, "#Policy.#Users = :users")
+		attrValues[":users"] = value
+	default:
+		attrNames["#Users"] = "users"
+		for key, keys := range users {
+			value, err := attributevalue.Marshal(keys)
+			if err != nil {
+				return err
+			}
+			attrKey := "#K_" + key
+			attrValue := ":V_" + key
+			attrNames[attrKey] = key
+			attrValues[attrValue] = value
+			setParts = append(setParts, fmt.Sprintf("#Policy.#Users.%s = %s", attrKey, attrValue))
+		}
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(cfgTableName),
+		Key:                       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: os.Getenv("CFG_KEY")}},
+		UpdateExpression:          aws.String("SET " + strings.Join(setParts, ", ")),
+		ExpressionAttributeNames:  attrNames,
+		ExpressionAttributeValues: attrValues,
+	}
+	_, err := GetDBClient().UpdateItem(context.TODO(), input)
+	if err != nil {
+		logrus.Errorf("Failed to update account policy, %v", err)
+	}
+	InvalidateConfig()
+	return err
+}
@@ -3,61 +3,32 @@ package dao
 import (
 	"fmt"
 	"msg-event/config"
+	"msg-event/i18n"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/avast/retry-go"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/aws-sdk-go-v2/service/support"
 	"github.com/aws/aws-sdk-go-v2/service/support/types"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
-const caseUrl = "https://support.console.aws.amazon.com/support/home#/case/?displayId=%s"
-
 var SupportClient *support.Client
 
-func GetSupportClient(c *Case) *support.Client {
-	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(getRegion()))
-	if err != nil {
-		logrus.Printf("Couldn't load default configuration. %v\n", err)
-		return nil
-	}
-
-	a, ok := config.Conf.Accounts[c.AccountKey]
-	if !ok {
-		panic("failed to get account " + c.AccountKey)
-	}
-
-	stsClient := sts.NewFromConfig(cfg)
-
-	provider := stscreds.NewAssumeRoleProvider(stsClient, a.RoleARN)
-
-	if provider == nil {
-		logrus.Errorf("counldn't create assume role provider.")
-		panic("failed to get provider by arn " + c.AccountKey)
-	}
-
-	cfg.Credentials = provider
-
-	return support.NewFromConfig(cfg)
-}
-
 // Deprecated
 func GetSupportClientByAKSK(c *Case) *support.Client {
 	if SupportClient != nil {
 		return SupportClient
 	}
 
-	a, ok := config.Conf.Accounts[c.AccountKey]
-	if !ok {
+	a, ok := config.Get().Accounts[c.AccountKey]
+	if !ok || a == nil {
 		panic("failed to get account " + c.AccountKey)
 	}
 	cfg, err := awsconfig.LoadDefaultConfig(context.TODO(),
@@ -85,30 +56,42 @@ func getRegion() string {
 	return "us-east-1"
 }
 
-// Create Case and Create Channel
-func CreateCaseAndChannel(c *Case) (*Case, error) {
-	client := GetSupportClient(c)
-	input := &support.CreateCaseInput{}
-
-	switch os.Getenv("CASE_LANGUAGE") {
+// CaseLanguage is the language of the support queue set by CASE_LANGUAGE,
+// en unless it is zh, ja or ko.
+func CaseLanguage() string {
+	switch l := os.Getenv("CASE_LANGUAGE"); l {
 	case "zh", "ja", "ko":
-		input.Language = aws.String(os.Getenv("CASE_LANGUAGE"))
+		return l
 	default:
-		input.Language = aws.String("en")
+		return "en"
+	}
+}
+
+// Create Case and Create Channel
+func CreateCaseAndChannel(c *Case) (*Case, error) {
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return nil, err
 	}
+	input := &support.CreateCaseInput{}
 
+	input.Language = aws.String(c.GetLanguage())
+	input.CcEmailAddresses = c.CcEmails
 	input.Subject = &c.Title
-	v := config.ServiceMap[c.ServiceCode]
-	input.ServiceCode = aws.String(v[0])
-	input.CategoryCode = aws.String(v[1])
+	// a service limit increase is a technical case of its own service
+	input.IssueType = aws.String(ISSUE_TECHNICAL)
+	if c.IssueType == ISSUE_BILLING {
+		input.IssueType = aws.String(ISSUE_BILLING)
+	}
+	input.ServiceCode = aws.String(c.ServiceCode)
+	input.CategoryCode = aws.String(c.CategoryCode)
 
-	v1 := config.SevMap[c.SevCode]
-	input.SeverityCode = aws.String(v1)
+	input.SeverityCode = aws.String(c.SevCode)
 	input.CommunicationBody = &c.Content
 
 	var response *support.CreateCaseOutput
 
-	err := retry.Do(
+	err = retry.Do(
 		func() error {
 			var err error
 			response, err = client.CreateCase(context.Background(), input)
@@ -120,7 +103,7 @@ func CreateCaseAndChannel(c *Case) (*Case, error) {
 	)
 	if err != nil {
 		logrus.Errorf("failed to create aws case %s", err)
-		return nil, err
+		return nil, supportErr(c.AccountKey, err)
 	}
 	c.CaseID = *response.CaseId
 	awsCase, err := GetAWSCase(c)
@@ -130,6 +113,7 @@ func CreateCaseAndChannel(c *Case) (*Case, error) {
 	}
 
 	displayCaseID := awsCase.Cases[0].DisplayId
+	c.SetAWSStatus(aws.ToString(awsCase.Cases[0].Status))
 
 	c.CaseID = *response.CaseId
 	c.DisplayCaseID = *displayCaseID
@@ -159,9 +143,14 @@ func CreateCaseAndChannel(c *Case) (*Case, error) {
 	}
 	c.CardRespMsgID = *rsp.Data.MessageId
 
+	a, err := getAccount(c.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+
 	/// Adding ChatTab with CASE URL
 	logrus.Info("Adding ChatTab with CASE URL")
-	url := fmt.Sprintf(caseUrl, c.DisplayCaseID)
+	url := GetCaseURL(a, c.DisplayCaseID)
 
 	err = CreateChatTab(c.ChannelID, url)
 
@@ -170,12 +159,6 @@ func CreateCaseAndChannel(c *Case) (*Case, error) {
 		return nil, err
 	}
 	c.CaseURL = url
-
-	a, ok := config.Conf.Accounts[c.AccountKey]
-	if !ok {
-		panic("failed to get account " + c.AccountKey)
-	}
-
 	c.CaseAccountID = GetAccountIdFromRoleARN(a.RoleARN)
 
 	c, err = UpsertCase(c)
@@ -188,7 +171,10 @@ func CreateCaseAndChannel(c *Case) (*Case, error) {
 }
 
 func AddAttToCase(c *Case, setID, name string) (caze *Case, err error) {
-	client := GetSupportClient(c)
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return nil, err
+	}
 
 	add := &support.AddCommunicationToCaseInput{
 		CaseId:            &c.CaseID,
@@ -215,11 +201,36 @@ func AddAttToCase(c *Case, setID, name string) (caze *Case, err error) {
 	return c, nil
 }
 
-func AddComment(c *Case, comment string) (caze *Case, err error) {
-	client := GetSupportClient(c)
-	r := regexp.MustCompile(`^@.+\s+`)
-	comment = r.ReplaceAllString(comment, "") // replace @user1
+// AddCCEmails adds the emails to the CC list of the case with a
+// communication that names them, the Support API can not remove CC emails.
+func AddCCEmails(c *Case, emails []string) error {
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return err
+	}
+	body := "Added to CC: " + strings.Join(emails, ", ")
+	err = retry.Do(
+		func() error {
+			_, err := client.AddCommunicationToCase(context.Background(), &support.AddCommunicationToCaseInput{
+				CaseId:            &c.CaseID,
+				CommunicationBody: &body,
+				CcEmailAddresses:  emails,
+			})
+			return err
+		},
+	)
+	if err != nil {
+		logrus.Errorf("failed to add cc emails %s", err)
+		return supportErr(c.AccountKey, err)
+	}
+	return nil
+}
 
+func AddComment(c *Case, comment string) (caze *Case, err error) {
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return nil, err
+	}
 	add := &support.AddCommunicationToCaseInput{
 		CaseId:            &c.CaseID,
 		CommunicationBody: &comment,
@@ -244,8 +255,35 @@ func AddComment(c *Case, comment string) (caze *Case, err error) {
 	return c, nil
 }
 
+// ResolveCase resolves the AWS case and returns its status before and after.
+func ResolveCase(c *Case) (initial, final string, err error) {
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return "", "", err
+	}
+
+	var resp *support.ResolveCaseOutput
+	err = retry.Do(
+		func() error {
+			var err error
+			resp, err = client.ResolveCase(context.Background(), &support.ResolveCaseInput{
+				CaseId: aws.String(c.CaseID),
+			})
+			return err
+		},
+	)
+	if err != nil {
+		logrus.Errorf("failed to resolve aws case %s, %v", c.CaseID, err)
+		return "", "", supportErr(c.AccountKey, err)
+	}
+	return aws.ToString(resp.InitialCaseStatus), aws.ToString(resp.FinalCaseStatus), nil
+}
+
 func GetAWSCase(c *Case) (caze *support.DescribeCasesOutput, err error) {
-	client := GetSupportClient(c)
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return nil, err
+	}
 
 	input := &support.DescribeCasesInput{
 		CaseIdList: []string{c.CaseID},
@@ -273,7 +311,10 @@ func GetAWSCase(c *Case) (caze *support.DescribeCasesOutput, err error) {
 
 func GetCaseComments(c *Case, ltime time.Time) (comments []types.Communication, err error) {
 	logrus.Infof("Starting to get case %s comments", *aws.String(c.DisplayCaseID))
-	client := GetSupportClient(c)
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return nil, err
+	}
 
 	input := &support.DescribeCommunicationsInput{
 		AfterTime: aws.String(FormatTime(ltime)),
@@ -300,7 +341,10 @@ func GetCaseComments(c *Case, ltime time.Time) (comments []types.Communication,
 }
 
 func AddAttachmentToCase(c *Case, name string, data []byte) error {
-	client := GetSupportClient(c)
+	client, err := GetSupportClient(c)
+	if err != nil {
+		return err
+	}
 	att := &support.AddAttachmentsToSetInput{
 		AttachmentSetId: nil,
 		Attachments: []types.Attachment{
@@ -312,7 +356,7 @@ func AddAttachmentToCase(c *Case, name string, data []byte) error {
 	}
 
 	var resp *support.AddAttachmentsToSetOutput
-	err := retry.Do(
+	err = retry.Do(
 		func() error {
 			var err error
 			resp, err = client.AddAttachmentsToSet(context.Background(), att)
@@ -338,10 +382,11 @@ func FormatTime(t time.Time) string {
 	return t.Format(time.RFC3339)
 }
 
-func FormatComments(comments []types.Communication) string {
+// FormatComments lists the replies of AWS support in the locale.
+func FormatComments(l i18n.Locale, comments []types.Communication) string {
 	cmt := ""
 	for _, c := range comments {
-		cmt += fmt.Sprintf("来自%s的最新回复(%s):\\n %s\\n", *c.SubmittedBy, *c.TimeCreated, *c.Body)
+		cmt += i18n.T(l, "comment.latest", *c.SubmittedBy, *c.TimeCreated) + fmt.Sprintf(":\\n %s\\n", *c.Body)
 	}
 
 	r := strings.NewReplacer("\n", "\\n", "\t", "\\t", "\r", "\\r")
@@ -350,19 +395,10 @@ func FormatComments(comments []types.Communication) string {
 	return s
 }
 
-func FormatMsg(caze *Case) string {
-	return fmt.Sprintln("工单创建必要内容缺失。请输入帮助关键字获取使用信息")
-}
-
 func GetAccountIdFromRoleARN(s string) string {
-	arn := s
-	re := regexp.MustCompile(`(?:::)(\d+)(?::)`)
-	match := re.FindStringSubmatch(arn)
-
-	if len(match) > 1 {
-		result := match[1]
-		return result
-	} else {
+	parsed, err := arn.Parse(s)
+	if err != nil || parsed.AccountID == "" {
 		return "0000"
 	}
+	return parsed.AccountID
 }
@@ -0,0 +1,227 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"msg-event/config"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const defaultSupportRoleName = "FeishuSupportCaseApiAll"
+
+// Organizations has a single endpoint per partition
+var organizationsRegion = map[string]string{
+	PartitionGlobal: "us-east-1",
+	PartitionChina:  "cn-northwest-1",
+}
+
+var ErrAccountSyncDisabled = errors.New("account sync is not enabled")
+
+// AccountSyncReport lists the changes of a sync, Unassumable are the accounts
+// whose role could not be assumed for the first time and Assumable the ones
+// that can be assumed again.
+type AccountSyncReport struct {
+	Added       []string
+	Removed     []string
+	Unassumable map[string]error
+	Assumable   []string
+}
+
+// SyncAccounts merges the active organization accounts into the configured
+// accounts, saves them and checks that the support roles of the new and the
+// unassumable accounts can be assumed. Accounts entered by hand are kept,
+// discovered accounts that left the organization are removed.
+func SyncAccounts(ctx context.Context) (*AccountSyncReport, error) {
+	conf := config.Get()
+	s := conf.AccountSync
+	if s == nil || !s.Enabled {
+		return nil, ErrAccountSyncDisabled
+	}
+	orgAccounts, err := listOrgAccounts(ctx, s)
+	if err != nil {
+		logrus.Errorf("failed to list organization accounts %v", err)
+		return nil, err
+	}
+
+	accounts, report, changed := mergeAccounts(conf.Accounts, orgAccounts, s)
+	if changed {
+		if err = SaveAccounts(accounts); err != nil {
+			return nil, err
+		}
+		if err = SetupConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	probe := func(key string) error { return CheckAccount(ctx, key) }
+	if probeAccounts(accounts, report, probe) {
+		if err = SaveAccounts(accounts); err != nil {
+			return nil, err
+		}
+		if err = SetupConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// probeAccounts checks the added accounts and the accounts that could not be
+// assumed before, and reports whether the Unassumable flag of any changed.
+func probeAccounts(accounts map[string]*config.Account, report *AccountSyncReport, probe func(key string) error) bool {
+	added := map[string]bool{}
+	for _, k := range report.Added {
+		added[k] = true
+	}
+	changed := false
+	for key, a := range accounts {
+		if !added[key] && !a.Unassumable {
+			continue
+		}
+		err := probe(key)
+		switch {
+		case err != nil && !a.Unassumable:
+			logrus.Warnf("account %s can not be assumed %v", key, err)
+			report.Unassumable[key] = err
+		case err == nil && a.Unassumable:
+			report.Assumable = append(report.Assumable, key)
+		default:
+			continue
+		}
+		a.Unassumable = err != nil
+		changed = true
+	}
+	sort.Strings(report.Assumable)
+	return changed
+}
+
+// CheckAccount gets credentials for the account, which assumes its role.
+func CheckAccount(ctx context.Context, key string) error {
+	client, err := GetSupportClient(&Case{AccountKey: key})
+	if err != nil {
+		return err
+	}
+	_, err = client.Options().Credentials.Retrieve(ctx)
+	return err
+}
+
+func SaveAccounts(accounts map[string]*config.Account) error {
+	av, err := attributevalue.Marshal(accounts)
+	if err != nil {
+		logrus.Errorf("Marshal accounts failed %v", err)
+		return err
+	}
+	_, err = GetDBClient().UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(cfgTableName),
+		Key:                       config.Get().GetKey(),
+		UpdateExpression:          aws.String("SET #Accounts = :accounts"),
+		ExpressionAttributeNames:  map[string]string{"#Accounts": "accounts"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":accounts": av},
+	})
+	if err != nil {
+		logrus.Errorf("Failed to update accounts, %v", err)
+		return err
+	}
+	InvalidateConfig()
+	return nil
+}
+
+func listOrgAccounts(ctx context.Context, s *config.AccountSync) ([]orgtypes.Account, error) {
+	partition := GetAccountPartition(&config.Account{Partition: s.Partition})
+	cfg, err := getBaseConfig(organizationsRegion[partition])
+	if err != nil {
+		return nil, err
+	}
+	if s.ManagementRoleARN != "" {
+		cfg.Credentials = aws.NewCredentialsCache(
+			stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), s.ManagementRoleARN))
+	}
+
+	var accounts []orgtypes.Account
+	p := organizations.NewListAccountsPaginator(organizations.NewFromConfig(cfg), &organizations.ListAccountsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Accounts {
+			if a.Status == orgtypes.AccountStatusActive {
+				accounts = append(accounts, a)
+			}
+		}
+	}
+	return accounts, nil
+}
+
+func mergeAccounts(current map[string]*config.Account, orgAccounts []orgtypes.Account,
+	s *config.AccountSync) (map[string]*config.Account, *AccountSyncReport, bool) {
+	report := &AccountSyncReport{Unassumable: map[string]error{}}
+	changed := false
+	partition := GetAccountPartition(&config.Account{Partition: s.Partition})
+
+	accounts := map[string]*config.Account{}
+	byID := map[string]string{}
+	for k, a := range current {
+		if a == nil {
+			continue
+		}
+		cp := *a
+		accounts[k] = &cp
+		if a.RoleARN != "" {
+			byID[GetAccountIdFromRoleARN(a.RoleARN)] = k
+		}
+	}
+
+	active := map[string]bool{}
+	for _, o := range orgAccounts {
+		id := aws.ToString(o.Id)
+		active[id] = true
+		if k, ok := byID[id]; ok {
+			name := aws.ToString(o.Name)
+			if (accounts[k].Discovered || accounts[k].Name == "") && accounts[k].Name != name {
+				accounts[k].Name = name
+				changed = true
+			}
+			continue
+		}
+		accounts[id] = &config.Account{
+			Name:       aws.ToString(o.Name),
+			RoleARN:    supportRoleARN(s.RolePattern, partition, id),
+			Partition:  partition,
+			Discovered: true,
+		}
+		report.Added = append(report.Added, id)
+	}
+
+	for k, a := range accounts {
+		if a.Discovered && !active[GetAccountIdFromRoleARN(a.RoleARN)] {
+			delete(accounts, k)
+			report.Removed = append(report.Removed, k)
+		}
+	}
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	changed = changed || len(report.Added) > 0 || len(report.Removed) > 0
+	return accounts, report, changed
+}
+
+func supportRoleARN(pattern, partition, accountID string) string {
+	if pattern == "" {
+		pattern = defaultSupportRoleName
+	}
+	if !strings.HasPrefix(pattern, "arn:") {
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountID, pattern)
+	}
+	return strings.NewReplacer("{partition}", partition, "{account_id}", accountID).Replace(pattern)
+}
@@ -0,0 +1,195 @@
+package dao
+
+import (
+	"errors"
+	"msg-event/config"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+)
+
+func orgAccount(id, name string) orgtypes.Account {
+	return orgtypes.Account{Id: aws.String(id), Name: aws.String(name)}
+}
+
+func TestMergeAccounts(t *testing.T) {
+	manual := func() *config.Account {
+		return &config.Account{Name: "prod", RoleARN: "arn:aws:iam::111111111111:role/Support"}
+	}
+	discovered := func(id, name string) *config.Account {
+		return &config.Account{Name: name, RoleARN: "arn:aws:iam::" + id + ":role/" + defaultSupportRoleName,
+			Partition: PartitionGlobal, Discovered: true}
+	}
+
+	tests := []struct {
+		name        string
+		current     map[string]*config.Account
+		org         []orgtypes.Account
+		sync        *config.AccountSync
+		want        map[string]*config.Account
+		wantAdded   []string
+		wantRemoved []string
+		wantChanged bool
+	}{
+		{
+			name:    "nothing changed",
+			current: map[string]*config.Account{"prod": manual(), "222222222222": discovered("222222222222", "dev")},
+			org:     []orgtypes.Account{orgAccount("111111111111", "Production"), orgAccount("222222222222", "dev")},
+			sync:    &config.AccountSync{},
+			want:    map[string]*config.Account{"prod": manual(), "222222222222": discovered("222222222222", "dev")},
+		},
+		{
+			name:        "new account is added with the default role",
+			current:     map[string]*config.Account{"prod": manual()},
+			org:         []orgtypes.Account{orgAccount("111111111111", "Production"), orgAccount("333333333333", "test")},
+			sync:        &config.AccountSync{},
+			want:        map[string]*config.Account{"prod": manual(), "333333333333": discovered("333333333333", "test")},
+			wantAdded:   []string{"333333333333"},
+			wantChanged: true,
+		},
+		{
+			name:    "role pattern with placeholders in the china partition",
+			current: map[string]*config.Account{},
+			org:     []orgtypes.Account{orgAccount("444444444444", "cn")},
+			sync:    &config.AccountSync{Partition: PartitionChina, RolePattern: "arn:{partition}:iam::{account_id}:role/Bot"},
+			want: map[string]*config.Account{"444444444444": {Name: "cn", RoleARN: "arn:aws-cn:iam::444444444444:role/Bot",
+				Partition: PartitionChina, Discovered: true}},
+			wantAdded:   []string{"444444444444"},
+			wantChanged: true,
+		},
+		{
+			name:    "role name pattern",
+			current: map[string]*config.Account{},
+			org:     []orgtypes.Account{orgAccount("555555555555", "ops")},
+			sync:    &config.AccountSync{RolePattern: "Bot"},
+			want: map[string]*config.Account{"555555555555": {Name: "ops", RoleARN: "arn:aws:iam::555555555555:role/Bot",
+				Partition: PartitionGlobal, Discovered: true}},
+			wantAdded:   []string{"555555555555"},
+			wantChanged: true,
+		},
+		{
+			name:        "discovered account follows the organization name",
+			current:     map[string]*config.Account{"222222222222": discovered("222222222222", "dev")},
+			org:         []orgtypes.Account{orgAccount("222222222222", "development")},
+			sync:        &config.AccountSync{},
+			want:        map[string]*config.Account{"222222222222": discovered("222222222222", "development")},
+			wantChanged: true,
+		},
+		{
+			name:        "manual account without name gets the organization name",
+			current:     map[string]*config.Account{"prod": {RoleARN: "arn:aws:iam::111111111111:role/Support"}},
+			org:         []orgtypes.Account{orgAccount("111111111111", "Production")},
+			sync:        &config.AccountSync{},
+			want:        map[string]*config.Account{"prod": {Name: "Production", RoleARN: "arn:aws:iam::111111111111:role/Support"}},
+			wantChanged: true,
+		},
+		{
+			name:        "closed discovered account is removed, manual account is kept",
+			current:     map[string]*config.Account{"prod": manual(), "222222222222": discovered("222222222222", "dev")},
+			org:         nil,
+			sync:        &config.AccountSync{},
+			want:        map[string]*config.Account{"prod": manual()},
+			wantRemoved: []string{"222222222222"},
+			wantChanged: true,
+		},
+		{
+			name:    "account with keys only is kept",
+			current: map[string]*config.Account{"keys": {AccessKeyID: "AKID", SecretAccessKey: "secret"}},
+			org:     nil,
+			sync:    &config.AccountSync{},
+			want:    map[string]*config.Account{"keys": {AccessKeyID: "AKID", SecretAccessKey: "secret"}},
+		},
+		{
+			name:    "empty account entry is skipped",
+			current: map[string]*config.Account{"prod": manual(), "broken": nil},
+			org:     []orgtypes.Account{orgAccount("111111111111", "Production")},
+			sync:    &config.AccountSync{},
+			want:    map[string]*config.Account{"prod": manual()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := map[string]config.Account{}
+			for k, a := range tt.current {
+				if a != nil {
+					before[k] = *a
+				}
+			}
+			got, report, changed := mergeAccounts(tt.current, tt.org, tt.sync)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("accounts\n got %+v\nwant %+v", deref(got), deref(tt.want))
+			}
+			if !reflect.DeepEqual(report.Added, tt.wantAdded) || !reflect.DeepEqual(report.Removed, tt.wantRemoved) {
+				t.Errorf("added %v removed %v, want %v %v", report.Added, report.Removed, tt.wantAdded, tt.wantRemoved)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed %v, want %v", changed, tt.wantChanged)
+			}
+			for k, a := range tt.current {
+				if a != nil && !reflect.DeepEqual(*a, before[k]) {
+					t.Errorf("current account %s was modified", k)
+				}
+			}
+		})
+	}
+}
+
+func TestProbeAccounts(t *testing.T) {
+	denied := errors.New("access denied")
+	accounts := map[string]*config.Account{
+		"new-ok":     {Discovered: true},
+		"new-denied": {Discovered: true},
+		"old-ok":     {},
+		"old-denied": {Unassumable: true},
+		"recovered":  {Discovered: true, Unassumable: true},
+	}
+	failing := map[string]bool{"new-denied": true, "old-denied": true, "old-ok": true}
+	var probed []string
+	report := &AccountSyncReport{Added: []string{"new-denied", "new-ok"}, Unassumable: map[string]error{}}
+
+	changed := probeAccounts(accounts, report, func(key string) error {
+		probed = append(probed, key)
+		if failing[key] {
+			return denied
+		}
+		return nil
+	})
+	sort.Strings(probed)
+	if want := []string{"new-denied", "new-ok", "old-denied", "recovered"}; !reflect.DeepEqual(probed, want) {
+		t.Errorf("probed %v, want %v", probed, want)
+	}
+	if !changed {
+		t.Error("flags changed, not reported")
+	}
+	if !reflect.DeepEqual(report.Unassumable, map[string]error{"new-denied": denied}) {
+		t.Errorf("unassumable %v, want only new-denied", report.Unassumable)
+	}
+	if !reflect.DeepEqual(report.Assumable, []string{"recovered"}) {
+		t.Errorf("assumable %v, want recovered", report.Assumable)
+	}
+	for k, want := range map[string]bool{"new-ok": false, "new-denied": true, "old-ok": false, "old-denied": true, "recovered": false} {
+		if accounts[k].Unassumable != want {
+			t.Errorf("%s unassumable %v, want %v", k, accounts[k].Unassumable, want)
+		}
+	}
+
+	// nothing changes on the next sync
+	report = &AccountSyncReport{Unassumable: map[string]error{}}
+	if probeAccounts(accounts, report, func(string) error { return denied }) {
+		t.Error("unchanged accounts reported as changed")
+	}
+	if len(report.Unassumable)+len(report.Assumable) != 0 {
+		t.Errorf("unchanged accounts reported %+v", report)
+	}
+}
+
+func deref(m map[string]*config.Account) map[string]config.Account {
+	out := map[string]config.Account{}
+	for k, a := range m {
+		out[k] = *a
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package dao
 import (
 	"context"
 	"msg-event/config"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -10,35 +11,77 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func getSecretValue(ctx context.Context, secretArn string) (string, error) {
+var (
+	secretCache    = newTTLCache[string](secretTTL)
+	secretsClient  *secretsmanager.Client
+	secretsClientM sync.Mutex
+)
 
+func getSecretsClient(ctx context.Context) (*secretsmanager.Client, error) {
+	secretsClientM.Lock()
+	defer secretsClientM.Unlock()
+	if secretsClient != nil {
+		return secretsClient, nil
+	}
 	cfg, err := awsconfig.LoadDefaultConfig(ctx)
-
 	if err != nil {
 		logrus.Errorf("failed to load AWS config: %s", err)
-		return "", err
+		return nil, err
 	}
+	secretsClient = secretsmanager.NewFromConfig(cfg)
+	return secretsClient, nil
+}
 
-	svc := secretsmanager.NewFromConfig(cfg)
-
-	output, err := svc.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(secretArn),
-	})
+// getSecretValue returns the secret string, cached for SECRET_TTL.
+func getSecretValue(ctx context.Context, secretArn string) (string, error) {
+	return secretCache.get(secretArn, func() (string, error) {
+		svc, err := getSecretsClient(ctx)
+		if err != nil {
+			return "", err
+		}
 
-	if err != nil {
-		logrus.Errorf("failed to get secret value: %s", err)
-		return "", err
-	}
+		output, err := svc.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(secretArn),
+		})
 
-	secretValue := aws.ToString(output.SecretString)
+		if err != nil {
+			logrus.Errorf("failed to get secret value: %s", err)
+			return "", err
+		}
 
-	return secretValue, nil
+		return aws.ToString(output.SecretString), nil
+	})
+}
 
+// InvalidateSecrets drops the cached secrets and the token derived from them,
+// call it after rotating the app secret.
+func InvalidateSecrets() {
+	secretCache.invalidateAll()
+	InvalidateToken()
 }
 
 func GetAppID() (string, error) {
-	return getSecretValue(context.Background(), config.Conf.AppIDARN)
+	return getSecretValue(context.Background(), config.Get().AppIDARN)
 }
 func GetAPPSecret() (string, error) {
-	return getSecretValue(context.Background(), config.Conf.AppSecretARN)
+	return getSecretValue(context.Background(), config.Get().AppSecretARN)
+}
+
+// GetEncryptKey returns the event encrypt key, or an empty string when the
+// bot is not configured with one.
+func GetEncryptKey() (string, error) {
+	conf := config.Get()
+	if conf.EncryptKeyARN == "" {
+		return "", nil
+	}
+	return getSecretValue(context.Background(), conf.EncryptKeyARN)
+}
+
+// GetVerificationToken returns the verification token of the event subscription.
+func GetVerificationToken() (string, error) {
+	conf := config.Get()
+	if conf.VerifyTokenARN == "" {
+		return "", nil
+	}
+	return getSecretValue(context.Background(), conf.VerifyTokenARN)
 }
@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Values below survive between warm invocations of the same Lambda container,
+// so they are only reloaded after the ttl or an explicit Invalidate call.
+var (
+	configTTL  = envDuration("CONFIG_TTL", 5*time.Minute)
+	secretTTL  = envDuration("SECRET_TTL", time.Hour)
+	contactTTL = envDuration("CONTACT_TTL", 10*time.Minute)
+)
+
+type cacheItem[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// ttlCache keeps loaded values for ttl, a failed load is never cached. The
+// lock is not held while loading, concurrent gets of a key share one load.
+type ttlCache[T any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]cacheItem[T]
+	calls map[string]*cacheCall[T]
+}
+
+// cacheCall is a load in flight, done is closed when it returns.
+type cacheCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func newTTLCache[T any](ttl time.Duration) *ttlCache[T] {
+	return &ttlCache[T]{
+		ttl:   ttl,
+		items: map[string]cacheItem[T]{},
+		calls: map[string]*cacheCall[T]{},
+	}
+}
+
+func (c *ttlCache[T]) get(key string, load func() (T, error)) (T, error) {
+	c.mu.Lock()
+	if item, ok := c.items[key]; ok && time.Now().Before(item.expires) {
+		c.mu.Unlock()
+		return item.value, nil
+	}
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &cacheCall[T]{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.value, call.err = load()
+
+	c.mu.Lock()
+	// an invalidate during the load drops the call, its value may be stale
+	if c.calls[key] == call {
+		delete(c.calls, key)
+		if call.err == nil {
+			c.items[key] = cacheItem[T]{value: call.value, expires: time.Now().Add(c.ttl)}
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
+
+func (c *ttlCache[T]) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	delete(c.calls, key)
+}
+
+func (c *ttlCache[T]) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = map[string]cacheItem[T]{}
+	c.calls = map[string]*cacheCall[T]{}
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		logrus.Warnf("invalid %s %q, use default %s", key, v, def)
+		return def
+	}
+	return d
+}
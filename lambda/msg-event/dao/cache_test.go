@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTTLCacheLoadsOutsideTheLock(t *testing.T) {
+	c := newTTLCache[string](time.Minute)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var loads atomic.Int32
+	slow := func() (string, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return "slow", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.get("a", slow)
+		}(i)
+		if i == 0 {
+			<-started
+		}
+	}
+
+	// another key loads while "a" is still loading
+	done := make(chan struct{})
+	go func() {
+		c.get("b", func() (string, error) { return "fast", nil })
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("get of another key waits for the load of a")
+	}
+
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("a loaded %d times, want once", n)
+	}
+	for i, r := range results {
+		if r != "slow" {
+			t.Errorf("get %d returned %q", i, r)
+		}
+	}
+}
+
+func TestTTLCacheErrorsAndInvalidate(t *testing.T) {
+	c := newTTLCache[int](time.Minute)
+	if _, err := c.get("k", func() (int, error) { return 0, errors.New("boom") }); err == nil {
+		t.Fatal("error not returned")
+	}
+	if v, _ := c.get("k", func() (int, error) { return 1, nil }); v != 1 {
+		t.Errorf("failed load was cached, got %d", v)
+	}
+	if v, _ := c.get("k", func() (int, error) { return 2, nil }); v != 1 {
+		t.Errorf("cached value not used, got %d", v)
+	}
+
+	// a value loaded across an invalidate is not kept
+	c.invalidate("k")
+	c.get("k", func() (int, error) {
+		c.invalidate("k")
+		return 3, nil
+	})
+	if v, _ := c.get("k", func() (int, error) { return 4, nil }); v != 4 {
+		t.Errorf("stale value kept after invalidate, got %d", v)
+	}
+}
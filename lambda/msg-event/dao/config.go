@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"msg-event/config"
+	"msg-event/i18n"
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -20,7 +21,46 @@ var SecretAccessKey = "YYYY"
 var AppID = "cli_xxxx"
 var AppSecret = "yyyy"
 
+var configCache = newTTLCache[*config.Config](configTTL)
+
+// SetupConfig loads the bot config for config.Get, the item is cached for
+// CONFIG_TTL so warm invocations skip the DynamoDB read.
 func SetupConfig() error {
+	c, err := configCache.get(os.Getenv(EnvConfigKey), loadConfig)
+	if err != nil {
+		return err
+	}
+	config.Set(c)
+	i18n.SetOverrides(configMessages(c))
+	return nil
+}
+
+// configMessages are the messages of the config, usage, ack and
+// no_permission_msg predate the catalog and override its zh-CN messages.
+func configMessages(c *config.Config) map[string]map[string]string {
+	messages := map[string]map[string]string{}
+	for l, msgs := range c.Messages {
+		messages[l] = msgs
+	}
+	legacy := map[string]string{}
+	for key, msg := range map[string]string{"usage": c.Usage, "comment.ack": c.Ack, "permission.denied": c.NoPermissionMSG} {
+		if msg != "" {
+			legacy[key] = msg
+		}
+	}
+	for key, msg := range messages[string(i18n.ZhCN)] {
+		legacy[key] = msg
+	}
+	messages[string(i18n.ZhCN)] = legacy
+	return messages
+}
+
+// InvalidateConfig drops the cached config, call it after writing the config item.
+func InvalidateConfig() {
+	configCache.invalidateAll()
+}
+
+func loadConfig() (*config.Config, error) {
 	client := GetDBClient()
 	// check existing request
 
@@ -33,45 +73,39 @@ func SetupConfig() error {
 	})
 	logrus.Infof("key: %v, cfg table: %v, result: %v, err: %v", c.Key, cfgTableName, result, err)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if result != nil && result.Item != nil {
-		config.Conf = convertCfg(result.Item)
-		config.Usage = config.Conf.Usage
-		config.SevMap = config.Conf.SevMap
-		config.ServiceMap = config.Conf.ServiceMap
-	} else {
-		c.Usage = config.Usage
-		c.ServiceMap = config.ServiceMap
-		c.SevMap = config.SevMap
-		c.Accounts = map[string]*config.Account{
-			"0": {
-				AccessKeyID:     AccessKeyID,
-				SecretAccessKey: SecretAccessKey,
-			},
-		}
+		return convertCfg(result.Item), nil
+	}
 
-		c.CaseCardTemplate = config.CardTemplate
-		c.ErrCardTemplate = config.ErrCardTemplate
-		item, err := attributevalue.MarshalMap(c)
+	c.Accounts = map[string]*config.Account{
+		"0": {
+			AccessKeyID:     AccessKeyID,
+			SecretAccessKey: SecretAccessKey,
+		},
+	}
 
-		if err != nil {
-			logrus.Errorf("Marshal map failed %v", err)
-		}
-		logrus.Infof("item is %s", item)
-		input := &dynamodb.PutItemInput{
-			Item:                   item,
-			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
-			TableName:              aws.String(cfgTableName),
-		}
-		_, err = client.PutItem(context.Background(), input)
+	c.CaseCardTemplate = config.CardTemplate
+	c.ErrCardTemplate = config.ErrCardTemplate
+	item, err := attributevalue.MarshalMap(c)
 
-		if err != nil {
-			logrus.Errorf("failed to put data %v", err)
-			return errors.New(config.BotConfigNotExisted)
-		}
+	if err != nil {
+		logrus.Errorf("Marshal map failed %v", err)
 	}
-	return nil
+	logrus.Infof("item is %s", item)
+	input := &dynamodb.PutItemInput{
+		Item:                   item,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		TableName:              aws.String(cfgTableName),
+	}
+	_, err = client.PutItem(context.Background(), input)
+
+	if err != nil {
+		logrus.Errorf("failed to put data %v", err)
+		return nil, errors.New("bot config is missing and the default config can not be created")
+	}
+	return c, nil
 }
 
 func convertCfg(attr map[string]types.AttributeValue) *config.Config {
@@ -0,0 +1,219 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkcontact "github.com/larksuite/oapi-sdk-go/v3/service/contact/v3"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// UserInfo is the part of the Lark contact we need for permission checks.
+type UserInfo struct {
+	UserID string
+	Name   string
+	Email  string
+	// Country picks the locale of the user, see GetLocale
+	Country       string
+	DepartmentIDs []string
+}
+
+var userCache = newTTLCache[*UserInfo](contactTTL)
+
+// GetUserInfo reads the user from the Lark contact, cached for CONTACT_TTL.
+// Department IDs are open_department_id.
+func GetUserInfo(userID string) (*UserInfo, error) {
+	return userCache.get(userID, func() (*UserInfo, error) {
+		resp, err := getClient().Contact.User.Get(context.Background(), larkcontact.NewGetUserReqBuilder().
+			UserId(userID).
+			UserIdType(larkcontact.UserIdTypeUserId).
+			DepartmentIdType(larkcontact.DepartmentIdTypeOpenDepartmentId).
+			Build())
+		if err != nil {
+			logrus.Errorf("failed to get user %s, %v", userID, err)
+			return nil, err
+		}
+		if !resp.Success() {
+			logrus.Errorf("failed to get user %s, %s", userID, larkcore.Prettify(resp.CodeError))
+			return nil, fmt.Errorf("get user failed, code %d, %s", resp.Code, resp.Msg)
+		}
+		u := &UserInfo{UserID: userID}
+		if resp.Data.User != nil {
+			if resp.Data.User.Name != nil {
+				u.Name = *resp.Data.User.Name
+			}
+			if resp.Data.User.EnterpriseEmail != nil {
+				u.Email = *resp.Data.User.EnterpriseEmail
+			}
+			if resp.Data.User.Email != nil && *resp.Data.User.Email != "" {
+				u.Email = *resp.Data.User.Email
+			}
+			if resp.Data.User.Country != nil {
+				u.Country = *resp.Data.User.Country
+			}
+			u.DepartmentIDs = resp.Data.User.DepartmentIds
+		}
+		return u, nil
+	})
+}
+
+var (
+	ancestorCache   = newTTLCache[[]string](contactTTL)
+	chatMemberCache = newTTLCache[map[string]bool](contactTTL)
+)
+
+// GetUserDepartments returns the departments of the user and all their parent
+// departments, so a whitelisted department includes its sub-departments.
+func GetUserDepartments(userID string) ([]string, error) {
+	u, err := GetUserInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var ids []string
+	for _, d := range u.DepartmentIDs {
+		parents, err := getDepartmentAncestors(d)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range append([]string{d}, parents...) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func getDepartmentAncestors(departmentID string) ([]string, error) {
+	return ancestorCache.get(departmentID, func() ([]string, error) {
+		var ids []string
+		pageToken := ""
+		for {
+			resp, err := getClient().Contact.Department.Parent(context.Background(), larkcontact.NewParentDepartmentReqBuilder().
+				DepartmentId(departmentID).
+				DepartmentIdType(larkcontact.DepartmentIdTypeOpenDepartmentId).
+				PageSize(50).
+				PageToken(pageToken).
+				Build())
+			if err != nil {
+				logrus.Errorf("failed to get parents of department %s, %v", departmentID, err)
+				return nil, err
+			}
+			if !resp.Success() {
+				return nil, fmt.Errorf("get parent departments failed, code %d, %s", resp.Code, resp.Msg)
+			}
+			for _, d := range resp.Data.Items {
+				if d.OpenDepartmentId != nil {
+					ids = append(ids, *d.OpenDepartmentId)
+				}
+			}
+			if resp.Data.HasMore == nil || !*resp.Data.HasMore || resp.Data.PageToken == nil {
+				return ids, nil
+			}
+			pageToken = *resp.Data.PageToken
+		}
+	})
+}
+
+// IsChatMember reports whether the user is in the chat, the member list of the
+// chat is cached for CONTACT_TTL. The bot has to be a member of the chat.
+func IsChatMember(chatID, userID string) (bool, error) {
+	members, err := getChatMembers(chatID)
+	if err != nil {
+		return false, err
+	}
+	return members[userID], nil
+}
+
+// GetChatMemberEmails returns the emails of the users in the chat, sorted,
+// members without email or whose contact can not be read are left out.
+func GetChatMemberEmails(chatID string) ([]string, error) {
+	members, err := getChatMembers(chatID)
+	if err != nil {
+		return nil, err
+	}
+	var emails []string
+	for userID := range members {
+		u, err := GetUserInfo(userID)
+		if err != nil {
+			logrus.Warnf("failed to get email of %s, %v", userID, err)
+			continue
+		}
+		if u.Email != "" {
+			emails = append(emails, u.Email)
+		}
+	}
+	sort.Strings(emails)
+	return emails, nil
+}
+
+func getChatMembers(chatID string) (map[string]bool, error) {
+	return chatMemberCache.get(chatID, func() (map[string]bool, error) {
+		members := map[string]bool{}
+		pageToken := ""
+		for {
+			resp, err := getClient().Im.ChatMembers.Get(context.Background(), larkim.NewGetChatMembersReqBuilder().
+				ChatId(chatID).
+				MemberIdType(larkim.MemberIdTypeUserId).
+				PageSize(100).
+				PageToken(pageToken).
+				Build())
+			if err != nil {
+				logrus.Errorf("failed to get members of chat %s, %v", chatID, err)
+				return nil, err
+			}
+			if !resp.Success() {
+				return nil, fmt.Errorf("get chat members failed, code %d, %s", resp.Code, resp.Msg)
+			}
+			for _, m := range resp.Data.Items {
+				if m.MemberId != nil {
+					members[*m.MemberId] = true
+				}
+			}
+			if resp.Data.HasMore == nil || !*resp.Data.HasMore || resp.Data.PageToken == nil {
+				return members, nil
+			}
+			pageToken = *resp.Data.PageToken
+		}
+	})
+}
+
+// GetDepartmentName returns the name of an open_department_id.
+func GetDepartmentName(departmentID string) (string, error) {
+	resp, err := getClient().Contact.Department.Get(context.Background(), larkcontact.NewGetDepartmentReqBuilder().
+		DepartmentId(departmentID).
+		DepartmentIdType(larkcontact.DepartmentIdTypeOpenDepartmentId).
+		Build())
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success() {
+		return "", fmt.Errorf("get department %s failed, code %d, %s", departmentID, resp.Code, resp.Msg)
+	}
+	if resp.Data.Department == nil || resp.Data.Department.Name == nil {
+		return departmentID, nil
+	}
+	return *resp.Data.Department.Name, nil
+}
+
+// GetChatName returns the name of a chat the bot is in.
+func GetChatName(chatID string) (string, error) {
+	resp, err := getClient().Im.Chat.Get(context.Background(), larkim.NewGetChatReqBuilder().
+		ChatId(chatID).
+		Build())
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success() {
+		return "", fmt.Errorf("get chat %s failed, code %d, %s", chatID, resp.Code, resp.Msg)
+	}
+	if resp.Data.Name == nil {
+		return chatID, nil
+	}
+	return *resp.Data.Name, nil
+}
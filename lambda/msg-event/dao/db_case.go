@@ -2,12 +2,13 @@ package dao
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"msg-event/i18n"
 	"msg-event/model"
 	"msg-event/model/event"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -21,21 +22,45 @@ import (
 )
 
 const (
-	STATUS_NEW      = "NEW"
-	STATUS_OPEN     = "OPEN"
-	STATUS_CLOSE    = "CLOSE"
-	TYPE_OPEN_CASE  = "OPEN_CASE"
-	TYPE_CASE       = "CASE"
-	SK              = "AWS_CASE"
-	GSI_NAME        = "status-type-index"
-	GSI_CREATE_TIME = "create-time-index"
-	GSI_MSG_ID      = "card_msg_id-index"
+	STATUS_NEW              = "NEW"
+	STATUS_PENDING_APPROVAL = "PENDING_APPROVAL"
+	STATUS_REJECTED         = "REJECTED"
+	STATUS_OPEN             = "OPEN"
+	STATUS_CLOSE            = "CLOSE"
+	APPROVAL_PENDING        = "PENDING"
+	APPROVAL_APPROVED       = "APPROVED"
+	APPROVAL_REJECTED       = "REJECTED"
+	TYPE_OPEN_CASE          = "OPEN_CASE"
+	TYPE_CASE               = "CASE"
+	SK                      = "AWS_CASE"
+	GSI_NAME                = "status-type-index"
+	GSI_CREATE_TIME         = "create-time-index"
+	GSI_MSG_ID              = "card_msg_id-index"
+	ISSUE_TECHNICAL         = "technical"
+	ISSUE_BILLING           = "customer-service"
+	ISSUE_LIMIT             = "service-limit-increase"
+)
+
+// the statuses of a case in AWS Support
+const (
+	AWS_STATUS_OPENED                    = "opened"
+	AWS_STATUS_UNASSIGNED                = "unassigned"
+	AWS_STATUS_WORK_IN_PROGRESS          = "work-in-progress"
+	AWS_STATUS_PENDING_CUSTOMER_ACTION   = "pending-customer-action"
+	AWS_STATUS_CUSTOMER_ACTION_COMPLETED = "customer-action-completed"
+	AWS_STATUS_REOPENED                  = "reopened"
+	AWS_STATUS_RESOLVED                  = "resolved"
 )
 
 var tableName = os.Getenv("CASES_TABLE")
-var DBClient *dynamodb.Client
+var (
+	DBClient   *dynamodb.Client
+	dbClientMu sync.Mutex
+)
 
 func GetDBClient() *dynamodb.Client {
+	dbClientMu.Lock()
+	defer dbClientMu.Unlock()
 	if DBClient != nil {
 		return DBClient
 	}
@@ -49,27 +74,25 @@ func GetDBClient() *dynamodb.Client {
 	// cfg.Region = os.Getenv("AWS_REGION")
 
 	// Using the Config value, create the DynamoDB client
-	DBClient := dynamodb.NewFromConfig(cfg)
+	DBClient = dynamodb.NewFromConfig(cfg)
 	return DBClient
 }
 
-// OpenCase every time rewrite the one case from this channel
-func OpenCase(fromChannelID, customerID, title, msgID string, msg *model.FeiShuMsg) (c *Case, err error) {
+// OpenCase every time rewrite the one case from this channel with the draft
+func OpenCase(draft *Case, fromChannelID, customerID, msgID string, msg *model.FeiShuMsg) (c *Case, err error) {
+	draft.UserID = customerID
+	draft.SortKey = SK
+	draft.ChannelID = fromChannelID
+	draft.FromChannelID = fromChannelID
+	draft.CreateTime = time.Now().String()
+	draft.UpdateTime = time.Now().String()
+	draft.Status = STATUS_NEW
+	draft.Type = TYPE_OPEN_CASE
+	draft.CardRespMsgID = msgID
+	draft.CardMsg = msg
 
 	// insert the data into dynamodb
-	ca, err := UpsertCase(&Case{
-		UserID:        customerID,
-		SortKey:       SK,
-		ChannelID:     fromChannelID,
-		FromChannelID: fromChannelID,
-		CreateTime:    time.Now().String(),
-		UpdateTime:    time.Now().String(),
-		Title:         title,
-		Status:        STATUS_NEW,
-		Type:          TYPE_OPEN_CASE,
-		CardRespMsgID: msgID,
-		CardMsg:       msg,
-	})
+	ca, err := UpsertCase(draft)
 	if err != nil {
 		logrus.Errorf("failed to update case for DDB %+v", err)
 		return nil, err
@@ -133,7 +156,7 @@ func GetCase(channelID string) (c *Case, err error) {
 	if result != nil && result.Item != nil {
 		return convert(result.Item), nil
 	}
-	return nil, errors.New("您还没有开case")
+	return nil, i18n.Errorf("case.not_opened")
 }
 
 func GetCaseByCardMSGID(msgID string) (c *Case, err error) {
@@ -159,7 +182,7 @@ func GetCaseByCardMSGID(msgID string) (c *Case, err error) {
 		return convert(resp.Items[0]), nil
 	} else {
 		logrus.Errorf("msg ID %v, resp %v", msgID, resp)
-		return nil, errors.New("没有找到工单卡片")
+		return nil, i18n.Errorf("case.card_not_found")
 	}
 }
 
@@ -215,13 +238,44 @@ func GetCasesByTime(t string) (cs []*Case, err error) {
 	return cs, nil
 }
 
+// ResolvedPollWindow is how long a resolved case is still polled, AWS Support
+// lets the case be reopened and commented on for 14 days.
+const ResolvedPollWindow = 14 * 24 * time.Hour
+
+// GetProcessingCases returns the open cases and the cases resolved within
+// ResolvedPollWindow, so a reopen or a late reply still reaches the group.
 func GetProcessingCases() (cs []*Case, err error) {
 	logrus.Infof("Start to get all un-closed cases")
-	client := GetDBClient()
-	resp, err := client.Query(context.Background(), &dynamodb.QueryInput{
+	open, err := queryCases(STATUS_OPEN, nil)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().UTC().Add(-ResolvedPollWindow).Format(time.RFC3339)
+	resolved, err := queryCases(STATUS_CLOSE, &expression{
+		filter: "#v_closed >= :v3",
+		names:  map[string]string{"#v_closed": "closed_at"},
+		values: map[string]types.AttributeValue{":v3": &types.AttributeValueMemberS{Value: cutoff}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Get all un-closed cases completed")
+	return append(open, resolved...), nil
+}
+
+// expression is an extra filter of queryCases.
+type expression struct {
+	filter string
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+// queryCases returns the cases in the status from the status index.
+func queryCases(status string, extra *expression) ([]*Case, error) {
+	input := &dynamodb.QueryInput{
 		KeyConditionExpression: aws.String("#v_status = :v1 AND #v_type = :v2"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":v1": &types.AttributeValueMemberS{Value: STATUS_OPEN},
+			":v1": &types.AttributeValueMemberS{Value: status},
 			":v2": &types.AttributeValueMemberS{Value: TYPE_CASE},
 		},
 		ExpressionAttributeNames: map[string]string{
@@ -230,16 +284,25 @@ func GetProcessingCases() (cs []*Case, err error) {
 		},
 		IndexName: aws.String(GSI_NAME),
 		TableName: aws.String(tableName),
-	})
+	}
+	if extra != nil {
+		input.FilterExpression = aws.String(extra.filter)
+		for k, v := range extra.names {
+			input.ExpressionAttributeNames[k] = v
+		}
+		for k, v := range extra.values {
+			input.ExpressionAttributeValues[k] = v
+		}
+	}
+	resp, err := GetDBClient().Query(context.Background(), input)
 	if err != nil {
-		logrus.Errorf("failed to list all cases %s", err)
+		logrus.Errorf("failed to list %s cases %s", status, err)
 		return nil, err
 	}
-	cs = make([]*Case, len(resp.Items))
+	cs := make([]*Case, len(resp.Items))
 	for i, v := range resp.Items {
 		cs[i] = convert(v)
 	}
-	logrus.Infof("Get all un-closed cases completed")
 	return cs, nil
 }
 
@@ -257,7 +320,9 @@ type Case struct {
 	CaseAccountID   string    `dynamodbav:"case_accountid"`
 	Content         string    `dynamodbav:"content"`
 	Status          string    `dynamodbav:"status"`
+	IssueType       string    `dynamodbav:"issue_type"`
 	ServiceCode     string    `dynamodbav:"service_code"`
+	CategoryCode    string    `dynamodbav:"category_code"`
 	SevCode         string    `dynamodbav:"sev_code"`
 	Type            string    `dynamodbav:"type"`
 	LastCommentTime time.Time `dynamodbav:"last_comment_time"`
@@ -265,6 +330,94 @@ type Case struct {
 	DisplayCaseID   string           `dynamodbav:"display_case_id"`
 	CardRespMsgID   string           `dynamodbav:"card_msg_id"`
 	CardMsg         *model.FeiShuMsg `dynamodbav:"card_msg"`
+	Limit           *LimitRequest    `dynamodbav:"limit_request"`
+	CcEmails        []string         `dynamodbav:"cc_emails"`
+	// Language is the language of the support queue, CASE_LANGUAGE when empty
+	Language string `dynamodbav:"language"`
+	// Confirming keeps a draft filled from a template until the user
+	// confirms it on the card
+	Confirming bool `dynamodbav:"confirming"`
+	// Locale is the locale of the draft card and the replies about the case
+	Locale string `dynamodbav:"locale"`
+	// ApprovalVersion is the UpdateTime of the draft the approval was asked
+	// for, a later edit needs a new approval
+	ApprovalStatus  string   `dynamodbav:"approval_status"`
+	ApprovalVersion string   `dynamodbav:"approval_version"`
+	ApprovalMsgIDs  []string `dynamodbav:"approval_msg_ids"`
+	Approver        string   `dynamodbav:"approver"`
+	ApprovalTime    string   `dynamodbav:"approval_time"`
+	// ResolvedBy resolved the case from Lark, the statuses are the ones AWS
+	// reported before and after
+	ResolvedBy           string `dynamodbav:"resolved_by"`
+	ResolveTime          string `dynamodbav:"resolve_time"`
+	ResolveInitialStatus string `dynamodbav:"resolve_initial_status"`
+	ResolveFinalStatus   string `dynamodbav:"resolve_final_status"`
+	// AWSStatus is the status of the case in AWS Support, StatusHistory
+	// keeps every change of it
+	AWSStatus     string         `dynamodbav:"aws_status"`
+	StatusHistory []StatusChange `dynamodbav:"status_history"`
+	// ClosedAt is when the case was closed, a closed case is polled for
+	// ResolvedPollWindow after it
+	ClosedAt time.Time `dynamodbav:"closed_at,omitempty"`
+}
+
+// StatusChange is a change of the AWS Support status of a case.
+type StatusChange struct {
+	From string    `dynamodbav:"from"`
+	To   string    `dynamodbav:"to"`
+	Time time.Time `dynamodbav:"time"`
+}
+
+// SetAWSStatus records the AWS Support status of the case and reports
+// whether it changed, a resolved case is closed and opened again when AWS
+// reopens it.
+func (c *Case) SetAWSStatus(status string) bool {
+	if status == "" || status == c.AWSStatus {
+		return false
+	}
+	from := c.AWSStatus
+	c.StatusHistory = append(c.StatusHistory, StatusChange{From: from, To: status, Time: time.Now()})
+	c.AWSStatus = status
+	switch {
+	case status == AWS_STATUS_RESOLVED:
+		c.Close()
+	case from == AWS_STATUS_RESOLVED && c.Status == STATUS_CLOSE:
+		c.Status = STATUS_OPEN
+		c.ClosedAt = time.Time{}
+	}
+	return true
+}
+
+// Close closes the case in the bot, it is still polled for ResolvedPollWindow.
+func (c *Case) Close() {
+	if c.Status != STATUS_CLOSE || c.ClosedAt.IsZero() {
+		c.ClosedAt = time.Now().UTC()
+	}
+	c.Status = STATUS_CLOSE
+}
+
+// LastStatusChange returns the latest change of the AWS Support status.
+func (c *Case) LastStatusChange() (StatusChange, bool) {
+	if len(c.StatusHistory) == 0 {
+		return StatusChange{}, false
+	}
+	return c.StatusHistory[len(c.StatusHistory)-1], true
+}
+
+// GetLanguage returns the language the case is opened in.
+func (c Case) GetLanguage() string {
+	if c.Language != "" {
+		return c.Language
+	}
+	return CaseLanguage()
+}
+
+// LimitRequest is the form of a service limit increase case, the service of
+// the quota is the CategoryCode of the case.
+type LimitRequest struct {
+	Quota  string `dynamodbav:"quota"`
+	Region string `dynamodbav:"region"`
+	Value  string `dynamodbav:"value"`
 }
 
 // GetKey returns the primary key of the case in a format that can be
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"msg-event/config"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -45,10 +46,12 @@ func AddWhitelist(whitelist map[string]string) (err error) {
 		// log.Fatalf("Failed to update item, %v", err)
 		logrus.Errorf("Failed to update item, %v", err)
 	}
+	InvalidateConfig()
 	return err
 }
 
 func DelWhiteList(whiteList map[string]string) (err error) {
+	conf := config.Get()
 	client := GetDBClient()
 	primaryKeyValue := os.Getenv("CFG_KEY")
 
@@ -59,10 +62,14 @@ func DelWhiteList(whiteList map[string]string) (err error) {
 		attrKey := "#K_" + key
 		updateExpParts = append(updateExpParts, fmt.Sprintf("#UserWhiteListMap.%s", attrKey))
 		attrNames[attrKey] = key
-		if _, ok := config.Conf.RoleMap[key]; ok {
+		if _, ok := conf.RoleMap[key]; ok {
 			attrNames["#RoleMap"] = "role"
 			updateExpParts = append(updateExpParts, fmt.Sprintf("#RoleMap.%s", attrKey))
 		}
+		if _, ok := conf.UserRoles[key]; ok {
+			attrNames["#UserRoles"] = "user_roles"
+			updateExpParts = append(updateExpParts, fmt.Sprintf("#UserRoles.%s", attrKey))
+		}
 	}
 
 	input := &dynamodb.UpdateItemInput{
@@ -76,25 +83,29 @@ func DelWhiteList(whiteList map[string]string) (err error) {
 	if err != nil {
 		logrus.Errorf("Failed to update item, %v", err)
 	}
+	InvalidateConfig()
 
 	return err
 }
 
-func GetWhiteList() (whiteList map[string]string) {
-
-	whiteList = make(map[string]string)
-	userList := make(map[string]string)
-	userList = config.Conf.UserWhiteListMap
-	roleMap := make(map[string]string)
-	roleMap = config.Conf.RoleMap
+type WhitelistEntry struct {
+	UserID string
+	// Contact is the email or phone the user was added with
+	Contact string
+	Role    config.Role
+}
 
-	for key, value := range userList {
-		if _, ok := roleMap[key]; !ok {
-			whiteList[value] = "Admin"
-		} else {
-			whiteList[value] = "User"
-		}
+// GetWhiteList returns the whitelisted users with their roles, sorted by
+// contact.
+func GetWhiteList() []WhitelistEntry {
+	conf := config.Get()
+	whiteList := make([]WhitelistEntry, 0, len(conf.UserWhiteListMap))
+	for key, value := range conf.UserWhiteListMap {
+		whiteList = append(whiteList, WhitelistEntry{UserID: key, Contact: value, Role: GetUserRole(key)})
 	}
+	sort.Slice(whiteList, func(i, j int) bool {
+		return whiteList[i].Contact < whiteList[j].Contact
+	})
 	return whiteList
 }
 
@@ -112,7 +123,7 @@ func SetAdmin(adminList map[string]string) (err error) {
 		attrKey := "#K_" + key
 		attrValue := ":V_" + key
 
-		if _, ok := config.Conf.UserWhiteListMap[key]; !ok {
+		if _, ok := config.Get().UserWhiteListMap[key]; !ok {
 			attrNames["#UserWhiteListMap"] = "user_whitelist"
 			updateExpParts = append(updateExpParts, fmt.Sprintf("#UserWhiteListMap.%s = %s", attrKey, attrValue))
 		}
@@ -134,6 +145,81 @@ func SetAdmin(adminList map[string]string) (err error) {
 	if err != nil {
 		logrus.Errorf("Failed to update item, %v", err)
 	}
+	InvalidateConfig()
+
+	return err
+}
+
+// AddDepartmentWhitelist whitelists departments, keyed by open_department_id
+// with the department name as value.
+func AddDepartmentWhitelist(departments map[string]string) error {
+	return updateGroupWhitelist("department_whitelist", config.Get().DepartmentWhiteList, departments, false)
+}
+
+func DelDepartmentWhitelist(departments map[string]string) error {
+	return updateGroupWhitelist("department_whitelist", config.Get().DepartmentWhiteList, departments, true)
+}
+
+// AddChatWhitelist whitelists chats, keyed by chat_id with the chat name as
+// value.
+func AddChatWhitelist(chats map[string]string) error {
+	return updateGroupWhitelist("chat_whitelist", config.Get().ChatWhiteList, chats, false)
+}
+
+func DelChatWhitelist(chats map[string]string) error {
+	return updateGroupWhitelist("chat_whitelist", config.Get().ChatWhiteList, chats, true)
+}
+
+func updateGroupWhitelist(attr string, current, items map[string]string, remove bool) (err error) {
+	if len(items) == 0 || (remove && current == nil) {
+		return nil
+	}
+	client := GetDBClient()
+	primaryKeyValue := os.Getenv("CFG_KEY")
+
+	var updateExpParts []string
+	attrNames := map[string]string{"#Whitelist": attr}
+	attrValues := map[string]types.AttributeValue{}
+
+	if current == nil && !remove {
+		// a nested path can only be set on an existing map
+		m := map[string]types.AttributeValue{}
+		for key, value := range items {
+			m[key] = &types.AttributeValueMemberS{Value: value}
+		}
+		updateExpParts = append(updateExpParts, "#Whitelist = :whitelist")
+		attrValues[":whitelist"] = &types.AttributeValueMemberM{Value: m}
+	} else {
+		for key, value := range items {
+			attrKey := "#K_" + strings.ReplaceAll(key, "-", "_")
+			attrNames[attrKey] = key
+			if remove {
+				updateExpParts = append(updateExpParts, fmt.Sprintf("#Whitelist.%s", attrKey))
+				continue
+			}
+			attrValue := ":V_" + strings.ReplaceAll(key, "-", "_")
+			updateExpParts = append(updateExpParts, fmt.Sprintf("#Whitelist.%s = %s", attrKey, attrValue))
+			attrValues[attrValue] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+	exp := "SET "
+	if remove {
+		exp = "REMOVE "
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName:                aws.String(cfgTableName),
+		Key:                      map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: primaryKeyValue}},
+		UpdateExpression:         aws.String(exp + strings.Join(updateExpParts, ", ")),
+		ExpressionAttributeNames: attrNames,
+	}
+	if len(attrValues) > 0 {
+		input.ExpressionAttributeValues = attrValues
+	}
 
+	_, err = client.UpdateItem(context.TODO(), input)
+	if err != nil {
+		logrus.Errorf("Failed to update item, %v", err)
+	}
+	InvalidateConfig()
 	return err
 }
@@ -7,9 +7,13 @@ import (
 	"fmt"
 	"io"
 	"msg-event/config"
+	"msg-event/i18n"
 	"msg-event/model"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	lark "github.com/larksuite/oapi-sdk-go/v3"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
@@ -80,6 +84,24 @@ func SendMsgToChannel(chatID, msg string) (resp *larkim.CreateMessageResp, err e
 	return SendMsg(chatID, "", msg)
 }
 
+// SendTextToChannel sends plain text with line breaks and quotes, unlike
+// SendMsgToChannel the text does not need to be escaped by the caller.
+func SendTextToChannel(chatID, text string) (resp *larkim.CreateMessageResp, err error) {
+	content, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return nil, err
+	}
+	return sendFeiShuMsg(getClient(), larkim.MsgTypeText, chatID, string(content))
+}
+
+var (
+	larkClient    *lark.Client
+	larkClientKey string
+	larkClientMu  sync.Mutex
+)
+
+// getClient reuses the client while the app credentials stay the same, the
+// SDK keeps its tenant token in a process wide cache.
 func getClient() *lark.Client {
 	id, err := GetAppID()
 	if err != nil {
@@ -90,9 +112,18 @@ func getClient() *lark.Client {
 		panic(err)
 	}
 
-	botEndpoint := os.Getenv("BOT_ENDPOINT")
+	larkClientMu.Lock()
+	defer larkClientMu.Unlock()
+	if larkClient == nil || larkClientKey != id+sec {
+		larkClient = lark.NewClient(id, sec, lark.WithOpenBaseUrl(OpenBaseURL()))
+		larkClientKey = id + sec
+	}
+	return larkClient
+}
 
-	var baseURL string
+// OpenBaseURL returns the open platform domain selected by BOT_ENDPOINT.
+func OpenBaseURL() string {
+	botEndpoint := os.Getenv("BOT_ENDPOINT")
 
 	// feishu Endpoint https://github.com/larksuite/oapi-sdk-go
 	var feishuBaseUrl = "https://open.feishu.cn"
@@ -102,24 +133,26 @@ func getClient() *lark.Client {
 
 	switch botEndpoint {
 	case "lark":
-		baseURL = larkBaseUrl
+		return larkBaseUrl
 	case "feishu":
-		baseURL = feishuBaseUrl
+		return feishuBaseUrl
 	default:
 		logrus.Warnf("Invalid bot endpoint %s, use default feishu endpoint", botEndpoint)
-		baseURL = feishuBaseUrl
+		return feishuBaseUrl
 	}
-
-	return lark.NewClient(id, sec, lark.WithOpenBaseUrl(baseURL))
 }
 
 func sendFeiShuMsg(client *lark.Client, t, chatId, msg string) (resp *larkim.CreateMessageResp, err error) {
+	return sendFeiShuMsgTo(client, larkim.ReceiveIdTypeChatId, t, chatId, msg)
+}
+
+func sendFeiShuMsgTo(client *lark.Client, idType, t, id, msg string) (resp *larkim.CreateMessageResp, err error) {
 
 	resp, err = client.Im.Message.Create(context.Background(), larkim.NewCreateMessageReqBuilder().
-		ReceiveIdType(larkim.ReceiveIdTypeChatId).
+		ReceiveIdType(idType).
 		Body(larkim.NewCreateMessageReqBodyBuilder().
 			MsgType(t).
-			ReceiveId(chatId).
+			ReceiveId(id).
 			Content(msg).
 			Build()).
 		Build())
@@ -156,11 +189,77 @@ func SendCardMsg(msgCard *model.FeiShuMsg, caze *Case) (*larkim.CreateMessageRes
 	return resp, nil
 }
 
+// SendCardToUser sends the card to the user in a private chat with the bot.
+func SendCardToUser(userID string, card *model.Card) (*larkim.CreateMessageResp, error) {
+	jsonStr, err := json.Marshal(card)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sendFeiShuMsgTo(getClient(), larkim.ReceiveIdTypeUserId, larkim.MsgTypeInteractive, userID, string(jsonStr))
+	if err != nil {
+		logrus.Errorf("Failed to send card msg to user %s, %v", userID, err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SendFile uploads data as a file and sends it to the chat.
+func SendFile(chatID, name string, data []byte) error {
+	client := getClient()
+	upload, err := client.Im.File.Create(context.Background(), larkim.NewCreateFileReqBuilder().
+		Body(larkim.NewCreateFileReqBodyBuilder().
+			FileType(larkim.FileTypeStream).
+			FileName(name).
+			File(bytes.NewReader(data)).
+			Build()).
+		Build())
+	if err != nil {
+		logrus.Errorf("Failed to upload file %s, %v", name, err)
+		return err
+	}
+	if !upload.Success() {
+		logrus.Errorf("Failed to upload file %s, %d %s", name, upload.Code, upload.Msg)
+		return fmt.Errorf("upload file failed, code %d, %s", upload.Code, upload.Msg)
+	}
+
+	content, err := (&larkim.MessageFile{FileKey: *upload.Data.FileKey}).String()
+	if err != nil {
+		return err
+	}
+	_, err = sendFeiShuMsg(client, larkim.MsgTypeFile, chatID, content)
+	return err
+}
+
+// UpdateCardMsg replaces the content of a card message that was already sent.
+func UpdateCardMsg(msgID string, card *model.Card) error {
+	jsonStr, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	resp, err := getClient().Im.Message.Patch(context.Background(), larkim.NewPatchMessageReqBuilder().
+		MessageId(msgID).
+		Body(larkim.NewPatchMessageReqBodyBuilder().
+			Content(string(jsonStr)).
+			Build()).
+		Build())
+	if err != nil {
+		logrus.Errorf("Failed to update card msg %s, %v", msgID, err)
+		return err
+	}
+	if !resp.Success() {
+		logrus.Errorf("Failed to update card msg %s, %d %s", msgID, resp.Code, resp.Msg)
+		return fmt.Errorf("update card failed, code %d, %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// SendErrCardMsg shows the error in the locale of the user in the chat.
 func SendErrCardMsg(chatId, userID string, e error) error {
-	config.Conf.ErrCardTemplate.Card.Elements[0].Content = e.Error()
-	config.Conf.ErrCardTemplate.ChatId = chatId
+	errCard := config.Get().ErrCardTemplate.Clone()
+	errCard.Card.Elements[0].Content = i18n.Message(GetLocale(chatId, userID), e)
+	errCard.ChatId = chatId
 
-	jsonStr, err := json.Marshal(config.Conf.ErrCardTemplate.Card)
+	jsonStr, err := json.Marshal(errCard.Card)
 	if err != nil {
 		return err
 	}
@@ -173,16 +272,51 @@ func SendErrCardMsg(chatId, userID string, e error) error {
 	return err
 }
 
+// tokenRefreshAhead renews the tenant token before Lark expires it
+const tokenRefreshAhead = 5 * time.Minute
+
+var (
+	tenantToken        *model.TokenResp
+	tenantTokenExpires time.Time
+	tenantTokenMu      sync.Mutex
+)
+
+// getToken returns the cached tenant access token and fetches a new one
+// shortly before it expires.
 func getToken() (t *model.TokenResp, err error) {
+	tenantTokenMu.Lock()
+	defer tenantTokenMu.Unlock()
+
+	if tenantToken != nil && time.Now().Before(tenantTokenExpires) {
+		return tenantToken, nil
+	}
+
+	t, err = requestToken()
+	if err != nil {
+		return nil, err
+	}
+	tenantToken = t
+	tenantTokenExpires = time.Now().Add(time.Duration(t.Expire)*time.Second - tokenRefreshAhead)
+	return t, nil
+}
+
+// InvalidateToken drops the cached tenant access token.
+func InvalidateToken() {
+	tenantTokenMu.Lock()
+	defer tenantTokenMu.Unlock()
+	tenantToken = nil
+}
+
+func requestToken() (t *model.TokenResp, err error) {
 
 	id, err := GetAppID()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	sec, err := GetAPPSecret()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	trq := &model.TokenReq{
@@ -195,6 +329,9 @@ func getToken() (t *model.TokenResp, err error) {
 		return nil, err
 	}
 	req, err := http.NewRequest("POST", tokenUrl, bytes.NewBuffer(jsonStr))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
@@ -206,12 +343,16 @@ func getToken() (t *model.TokenResp, err error) {
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	logrus.Infof("get token response Body: %s", string(body))
 	t = &model.TokenResp{}
 	err = json.Unmarshal(body, t)
 	if err != nil {
 		return nil, err
 	}
+	if t.Code != 0 {
+		logrus.Errorf("get token failed, code %d, msg %s", t.Code, t.Msg)
+		return nil, fmt.Errorf("get token failed, code %d, msg %s", t.Code, t.Msg)
+	}
+	logrus.Infof("got tenant token, expire in %ds", t.Expire)
 	return t, nil
 }
 
@@ -356,3 +497,61 @@ func GetUserIdbyEmailOrPhone(emailList []string, phoneList []string) (validUser
 
 	return validUser, badUserList, nil
 }
+
+// batchGetIDLimit is the max number of emails, and of mobiles, per BatchGetId
+const batchGetIDLimit = 50
+
+// ResolveContacts maps every email or phone that belongs to a Lark user to the
+// user id, contacts without a user are missing from the result.
+func ResolveContacts(contacts []string) (map[string]string, error) {
+	var emails, phones []string
+	for _, c := range contacts {
+		if strings.Contains(c, "@") {
+			emails = append(emails, c)
+		} else {
+			phones = append(phones, c)
+		}
+	}
+
+	byContact := map[string]string{}
+	for len(emails) > 0 || len(phones) > 0 {
+		e := emails[:min(len(emails), batchGetIDLimit)]
+		p := phones[:min(len(phones), batchGetIDLimit)]
+		emails, phones = emails[len(e):], phones[len(p):]
+
+		validUser, _, err := GetUserIdbyEmailOrPhone(e, p)
+		if err != nil {
+			return nil, err
+		}
+		for userID, contact := range validUser {
+			byContact[normalizeContact(contact)] = userID
+		}
+	}
+
+	result := map[string]string{}
+	for _, c := range contacts {
+		if userID, ok := byContact[normalizeContact(c)]; ok {
+			result[c] = userID
+		}
+	}
+	return result, nil
+}
+
+// normalizeContact lowercases emails and reduces phones to their digits
+// without the +86 prefix, Lark may return them in another format.
+func normalizeContact(c string) string {
+	c = strings.TrimSpace(c)
+	if strings.Contains(c, "@") {
+		return strings.ToLower(c)
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, c)
+	if len(digits) == 13 && strings.HasPrefix(digits, "86") {
+		digits = digits[2:]
+	}
+	return digits
+}
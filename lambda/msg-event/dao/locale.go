@@ -0,0 +1,120 @@
+package dao
+
+import (
+	"fmt"
+	"msg-event/config"
+	"msg-event/i18n"
+	"msg-event/model"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// zhCountries are the countries of the Lark profile that get zh-CN.
+var zhCountries = map[string]bool{"CN": true, "HK": true, "MO": true, "TW": true}
+
+// GetLocale picks the locale of the replies to the user in the chat: the
+// locale set for the chat, the locale the user set, the country of the Lark
+// profile of the user, then DefaultLocale.
+func GetLocale(chatID, userID string) i18n.Locale {
+	conf := config.Get()
+	if l, ok := i18n.Parse(conf.ChatLocales[chatID]); ok && chatID != "" {
+		return l
+	}
+	if userID != "" {
+		if l, ok := i18n.Parse(conf.UserLocales[userID]); ok {
+			return l
+		}
+		u, err := GetUserInfo(userID)
+		if err != nil {
+			logrus.Warnf("failed to get locale of user %s, %v", userID, err)
+		} else if u.Country != "" {
+			if zhCountries[strings.ToUpper(u.Country)] {
+				return i18n.ZhCN
+			}
+			return i18n.EnUS
+		}
+	}
+	return DefaultLocale()
+}
+
+// DefaultLocale is the locale of the tenant, zh-CN unless the config sets
+// another.
+func DefaultLocale() i18n.Locale {
+	if l, ok := i18n.Parse(config.Get().DefaultLocale); ok {
+		return l
+	}
+	return i18n.Default
+}
+
+// SetChatLocale sets the locale of the replies in a chat.
+func SetChatLocale(chatID string, l i18n.Locale) error {
+	return setLocale("chat_locales", config.Get().ChatLocales, chatID, l)
+}
+
+// SetUserLocale sets the locale of the replies to a user.
+func SetUserLocale(userID string, l i18n.Locale) error {
+	return setLocale("user_locales", config.Get().UserLocales, userID, l)
+}
+
+func setLocale(attr string, current map[string]string, id string, l i18n.Locale) error {
+	exp := "SET #Locales.#ID = :locale"
+	values := map[string]types.AttributeValue{":locale": &types.AttributeValueMemberS{Value: string(l)}}
+	if current == nil {
+		// a nested path can only be set on an existing map
+		exp = "SET #Locales = :locales"
+		values = map[string]types.AttributeValue{":locales": &types.AttributeValueMemberM{
+			Value: map[string]types.AttributeValue{id: values[":locale"]},
+		}}
+	}
+	names := map[string]string{"#Locales": attr}
+	if current != nil {
+		names["#ID"] = id
+	}
+	_, err := GetDBClient().UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(cfgTableName),
+		Key:                       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: os.Getenv("CFG_KEY")}},
+		UpdateExpression:          aws.String(exp),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		logrus.Errorf("Failed to set %s of %s, %v", attr, id, err)
+	}
+	InvalidateConfig()
+	return err
+}
+
+// RoleName is the name of the role in the locale.
+func RoleName(l i18n.Locale, r config.Role) string {
+	if r == config.RoleNone {
+		return i18n.T(l, "role.none")
+	}
+	return i18n.T(l, fmt.Sprintf("role.%s", r))
+}
+
+// CaseLocale is the locale of the replies about the case, the locale of the
+// draft or else the locale of its chat and user.
+func CaseLocale(c *Case) i18n.Locale {
+	if l, ok := i18n.Parse(c.Locale); ok {
+		return l
+	}
+	return GetLocale(c.ChannelID, c.UserID)
+}
+
+// CaseCardTemplate is the case card template of the locale, the default
+// template when the config has none for it.
+func CaseCardTemplate(l i18n.Locale) *model.FeiShuMsg {
+	conf := config.Get()
+	for s, t := range conf.CaseCardTemplates {
+		if p, ok := i18n.Parse(s); ok && p == l && t != nil {
+			return t
+		}
+	}
+	return conf.CaseCardTemplate
+}
@@ -0,0 +1,195 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"msg-event/config"
+	"msg-event/i18n"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+var ErrNoPermission = errors.New("no permission")
+
+func whitelistEnabled() bool {
+	return os.Getenv("ENABLE_USER_WHITELIST") == "true"
+}
+
+// GetUserRole resolves the role of the user. Users in RoleMap are admins,
+// then the role set in UserRoles wins, otherwise whitelisted users, or all
+// users when the whitelist is disabled, get DefaultRole.
+func GetUserRole(userID string) config.Role {
+	conf := config.Get()
+	if _, ok := conf.RoleMap[userID]; ok {
+		return config.RoleAdmin
+	}
+	if r, ok := conf.UserRoles[userID]; ok {
+		return r
+	}
+	if !whitelistEnabled() || IsWhitelisted(userID) {
+		return defaultRole()
+	}
+	return config.RoleNone
+}
+
+// IsWhitelisted checks the user whitelist, then the whitelisted departments
+// and chats through the cached Lark contact.
+func IsWhitelisted(userID string) bool {
+	conf := config.Get()
+	if _, ok := conf.UserWhiteListMap[userID]; ok {
+		return true
+	}
+	if len(conf.DepartmentWhiteList) > 0 {
+		departments, err := GetUserDepartments(userID)
+		if err != nil {
+			logrus.Errorf("failed to get departments of %s, %v", userID, err)
+		}
+		for _, d := range departments {
+			if _, ok := conf.DepartmentWhiteList[d]; ok {
+				return true
+			}
+		}
+	}
+	for chatID := range conf.ChatWhiteList {
+		ok, err := IsChatMember(chatID, userID)
+		if err != nil {
+			logrus.Errorf("failed to check member of chat %s, %v", chatID, err)
+			continue
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultRole() config.Role {
+	conf := config.Get()
+	if conf.DefaultRole.Valid() {
+		return conf.DefaultRole
+	}
+	return config.RoleSubmitter
+}
+
+// CheckPermission returns ErrNoPermission when the role of the user does not
+// cover required.
+func CheckPermission(userID string, required config.Role) error {
+	if r := GetUserRole(userID); !r.Covers(required) {
+		logrus.Warnf("user %s with role %q requires %q", userID, r, required)
+		return ErrNoPermission
+	}
+	return nil
+}
+
+// GetUsersWithRole returns the users with an explicit role covering role,
+// sorted.
+func GetUsersWithRole(role config.Role) []string {
+	conf := config.Get()
+	users := map[string]bool{}
+	for id := range conf.RoleMap {
+		users[id] = true
+	}
+	for id, r := range conf.UserRoles {
+		if r.Covers(role) {
+			users[id] = true
+		}
+	}
+	ids := make([]string, 0, len(users))
+	for id := range users {
+		if GetUserRole(id).Covers(role) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SendNoPermissionCard is the one answer to every denied command.
+func SendNoPermissionCard(chatID, userID string, required config.Role) error {
+	l := GetLocale(chatID, userID)
+	return SendErrCardMsg(chatID, userID,
+		i18n.Errorf("permission.denied_role", i18n.T(l, "permission.denied"), RoleName(l, required)))
+}
+
+// SetRole sets the role of the users, the users are added to the whitelist
+// and removed from the legacy admin RoleMap.
+func SetRole(users map[string]string, role config.Role) (err error) {
+	client := GetDBClient()
+	primaryKeyValue := os.Getenv("CFG_KEY")
+
+	exp, attrNames, attrValues := roleUpdate(config.Get(), users, role)
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(cfgTableName),
+		Key:                       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: primaryKeyValue}},
+		UpdateExpression:          aws.String(exp),
+		ExpressionAttributeNames:  attrNames,
+		ExpressionAttributeValues: attrValues,
+	}
+
+	_, err = client.UpdateItem(context.TODO(), input)
+	if err != nil {
+		logrus.Errorf("Failed to update item, %v", err)
+	}
+	InvalidateConfig()
+
+	return err
+}
+
+// roleUpdate builds the update expression of SetRole. DynamoDB rejects
+// attribute names the expression does not use, so a user key only gets a
+// name when a path refers to it.
+func roleUpdate(conf *config.Config, users map[string]string, role config.Role) (string, map[string]string, map[string]types.AttributeValue) {
+	var setParts, removeParts []string
+	attrNames := map[string]string{"#UserRoles": "user_roles"}
+	attrValues := map[string]types.AttributeValue{}
+	roleValue := &types.AttributeValueMemberS{Value: string(role)}
+
+	if conf.UserRoles == nil {
+		// a nested path can only be set on an existing map
+		roles := map[string]types.AttributeValue{}
+		for key := range users {
+			roles[key] = roleValue
+		}
+		setParts = append(setParts, "#UserRoles = :roles")
+		attrValues[":roles"] = &types.AttributeValueMemberM{Value: roles}
+	} else {
+		attrValues[":role"] = roleValue
+	}
+
+	for key, value := range users {
+		attrKey := "#K_" + key
+		attrValue := ":V_" + key
+
+		if _, ok := conf.UserWhiteListMap[key]; !ok {
+			attrNames["#UserWhiteListMap"] = "user_whitelist"
+			attrNames[attrKey] = key
+			setParts = append(setParts, fmt.Sprintf("#UserWhiteListMap.%s = %s", attrKey, attrValue))
+			attrValues[attrValue] = &types.AttributeValueMemberS{Value: value}
+		}
+		if conf.UserRoles != nil {
+			attrNames[attrKey] = key
+			setParts = append(setParts, fmt.Sprintf("#UserRoles.%s = :role", attrKey))
+		}
+		if _, ok := conf.RoleMap[key]; ok {
+			attrNames["#RoleMap"] = "role"
+			attrNames[attrKey] = key
+			removeParts = append(removeParts, fmt.Sprintf("#RoleMap.%s", attrKey))
+		}
+	}
+
+	// map order would make the expression differ on each call
+	sort.Strings(setParts)
+	sort.Strings(removeParts)
+	exp := "SET " + strings.Join(setParts, ", ")
+	if len(removeParts) > 0 {
+		exp += " REMOVE " + strings.Join(removeParts, ", ")
+	}
+	return exp, attrNames, attrValues
+}
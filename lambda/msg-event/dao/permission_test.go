@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"msg-event/config"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestRoleUpdate(t *testing.T) {
+	users := map[string]string{"ou_new": "New", "ou_known": "Known"}
+	tests := []struct {
+		name     string
+		conf     *config.Config
+		wantExp  string
+		wantRole bool
+	}{
+		{
+			name:    "no user roles yet, whitelisted user",
+			conf:    &config.Config{UserWhiteListMap: map[string]string{"ou_known": "Known"}},
+			wantExp: "SET #UserRoles = :roles, #UserWhiteListMap.#K_ou_new = :V_ou_new",
+		},
+		{
+			name:    "no user roles yet, all users whitelisted",
+			conf:    &config.Config{UserWhiteListMap: map[string]string{"ou_known": "Known", "ou_new": "New"}},
+			wantExp: "SET #UserRoles = :roles",
+		},
+		{
+			name: "existing user roles and legacy admin",
+			conf: &config.Config{
+				UserWhiteListMap: map[string]string{"ou_known": "Known"},
+				UserRoles:        map[string]config.Role{},
+				RoleMap:          map[string]string{"ou_known": "admin"},
+			},
+			wantExp:  "SET #UserRoles.#K_ou_known = :role, #UserRoles.#K_ou_new = :role, #UserWhiteListMap.#K_ou_new = :V_ou_new REMOVE #RoleMap.#K_ou_known",
+			wantRole: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, names, values := roleUpdate(tt.conf, users, config.RoleAdmin)
+			if exp != tt.wantExp {
+				t.Errorf("expression %q, want %q", exp, tt.wantExp)
+			}
+			assertPlaceholders(t, exp, `#\w+`, keys(names))
+			assertPlaceholders(t, exp, `:\w+`, keys(values))
+			if _, ok := values[":role"]; ok != tt.wantRole {
+				t.Errorf(":role set %v, want %v", ok, tt.wantRole)
+			}
+		})
+	}
+}
+
+// assertPlaceholders checks the expression uses exactly the given names,
+// DynamoDB rejects unused ones.
+func assertPlaceholders(t *testing.T, exp, pattern string, want []string) {
+	t.Helper()
+	used := map[string]bool{}
+	for _, p := range regexp.MustCompile(pattern).FindAllString(exp, -1) {
+		used[p] = true
+	}
+	got := keys(used)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("expression uses %v, defined %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("expression uses %v, defined %v", got, want)
+		}
+	}
+}
+
+func keys[V any](m map[string]V) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
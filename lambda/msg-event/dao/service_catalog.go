@@ -0,0 +1,170 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/support"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// catalogTTL is how long a catalog stored in DynamoDB is used before it is
+// synced again from DescribeServices.
+var catalogTTL = envDuration("CATALOG_TTL", 24*time.Hour)
+
+type CatalogCategory struct {
+	Code string `dynamodbav:"code"`
+	Name string `dynamodbav:"name"`
+}
+
+type CatalogService struct {
+	Code       string            `dynamodbav:"code"`
+	Name       string            `dynamodbav:"name"`
+	Categories []CatalogCategory `dynamodbav:"categories"`
+}
+
+// ServiceCatalog is the result of DescribeServices for an account and
+// language, stored in the config table, services are sorted by name.
+type ServiceCatalog struct {
+	Key      string           `dynamodbav:"key"`
+	Services []CatalogService `dynamodbav:"services"`
+	SyncTime time.Time        `dynamodbav:"sync_time"`
+}
+
+var catalogCache = newTTLCache[*ServiceCatalog](configTTL)
+
+func catalogKey(accountKey, language string) string {
+	return fmt.Sprintf("service_catalog#%s#%s", accountKey, language)
+}
+
+// GetServiceCatalog returns the services of the account in the language.
+// The catalog is synced when it is missing or older than CATALOG_TTL, a stale
+// catalog is still used when the sync fails.
+func GetServiceCatalog(accountKey, language string) (*ServiceCatalog, error) {
+	key := catalogKey(accountKey, language)
+	return catalogCache.get(key, func() (*ServiceCatalog, error) {
+		stored, err := getStoredCatalog(key)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil && time.Since(stored.SyncTime) < catalogTTL {
+			return stored, nil
+		}
+
+		synced, err := SyncServiceCatalog(accountKey, language)
+		if err != nil {
+			if stored != nil {
+				logrus.Warnf("failed to sync service catalog %s, use the one synced at %s, %v", key, stored.SyncTime, err)
+				return stored, nil
+			}
+			return nil, err
+		}
+		return synced, nil
+	})
+}
+
+// SyncServiceCatalog reads the services of the account from DescribeServices
+// and stores them.
+func SyncServiceCatalog(accountKey, language string) (*ServiceCatalog, error) {
+	client, err := GetSupportClient(&Case{AccountKey: accountKey})
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.DescribeServices(context.Background(), &support.DescribeServicesInput{
+		Language: aws.String(language),
+	})
+	if err != nil {
+		logrus.Errorf("failed to describe services of account %s, %v", accountKey, err)
+		return nil, supportErr(accountKey, err)
+	}
+
+	catalog := &ServiceCatalog{
+		Key:      catalogKey(accountKey, language),
+		SyncTime: time.Now(),
+	}
+	for _, s := range out.Services {
+		service := CatalogService{Code: aws.ToString(s.Code), Name: aws.ToString(s.Name)}
+		for _, c := range s.Categories {
+			service.Categories = append(service.Categories, CatalogCategory{Code: aws.ToString(c.Code), Name: aws.ToString(c.Name)})
+		}
+		catalog.Services = append(catalog.Services, service)
+	}
+	sort.Slice(catalog.Services, func(i, j int) bool {
+		return catalog.Services[i].Name < catalog.Services[j].Name
+	})
+
+	item, err := attributevalue.MarshalMap(catalog)
+	if err != nil {
+		return nil, err
+	}
+	_, err = GetDBClient().PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(cfgTableName),
+		Item:      item,
+	})
+	if err != nil {
+		logrus.Errorf("failed to store service catalog %s, %v", catalog.Key, err)
+		return nil, err
+	}
+	return catalog, nil
+}
+
+func getStoredCatalog(key string) (*ServiceCatalog, error) {
+	result, err := GetDBClient().GetItem(context.Background(), &dynamodb.GetItemInput{
+		Key:       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+		TableName: aws.String(cfgTableName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+	catalog := &ServiceCatalog{}
+	if err = attributevalue.UnmarshalMap(result.Item, catalog); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// Service returns the service with the code.
+func (c *ServiceCatalog) Service(code string) (*CatalogService, bool) {
+	for i := range c.Services {
+		if c.Services[i].Code == code {
+			return &c.Services[i], true
+		}
+	}
+	return nil, false
+}
+
+// Search returns the services whose code or name contains the keyword,
+// ignoring case. A service whose code is the keyword comes first.
+func (c *ServiceCatalog) Search(keyword string) []CatalogService {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	var exact, matches []CatalogService
+	for _, s := range c.Services {
+		switch {
+		case strings.ToLower(s.Code) == keyword:
+			exact = append(exact, s)
+		case strings.Contains(strings.ToLower(s.Code), keyword) || strings.Contains(strings.ToLower(s.Name), keyword):
+			matches = append(matches, s)
+		}
+	}
+	return append(exact, matches...)
+}
+
+// Category returns the category of the service with the code or name.
+func (s *CatalogService) Category(codeOrName string) (*CatalogCategory, bool) {
+	for i := range s.Categories {
+		if s.Categories[i].Code == codeOrName || strings.EqualFold(s.Categories[i].Name, codeOrName) {
+			return &s.Categories[i], true
+		}
+	}
+	return nil, false
+}
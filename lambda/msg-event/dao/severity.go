@@ -0,0 +1,43 @@
+package dao
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/support"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// SeverityLevel is a severity the support plan of an account allows.
+type SeverityLevel struct {
+	Code string
+	Name string
+}
+
+var severityCache = newTTLCache[[]SeverityLevel](catalogTTL)
+
+// GetSeverityLevels returns the severities of the account from
+// DescribeSeverityLevels in the language, lowest first, cached for
+// CATALOG_TTL. An account without support plan gets an AccountError with
+// ErrNoSupportPlan.
+func GetSeverityLevels(accountKey, language string) ([]SeverityLevel, error) {
+	return severityCache.get(fmt.Sprintf("%s#%s", accountKey, language), func() ([]SeverityLevel, error) {
+		client, err := GetSupportClient(&Case{AccountKey: accountKey})
+		if err != nil {
+			return nil, err
+		}
+		out, err := client.DescribeSeverityLevels(context.Background(), &support.DescribeSeverityLevelsInput{
+			Language: aws.String(language),
+		})
+		if err != nil {
+			logrus.Errorf("failed to describe severity levels of account %s, %v", accountKey, err)
+			return nil, supportErr(accountKey, err)
+		}
+		levels := make([]SeverityLevel, 0, len(out.SeverityLevels))
+		for _, l := range out.SeverityLevels {
+			levels = append(levels, SeverityLevel{Code: aws.ToString(l.Code), Name: aws.ToString(l.Name)})
+		}
+		return levels, nil
+	})
+}
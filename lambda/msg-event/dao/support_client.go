@@ -0,0 +1,226 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"msg-event/config"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/aws-sdk-go-v2/service/support"
+	"github.com/aws/smithy-go"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	PartitionGlobal = "aws"
+	PartitionChina  = "aws-cn"
+)
+
+// Support API endpoints only live in one region per partition
+var partitionRegion = map[string]string{
+	PartitionGlobal: "us-east-1",
+	PartitionChina:  "cn-north-1",
+}
+
+var partitionCaseURL = map[string]string{
+	PartitionGlobal: "https://support.console.aws.amazon.com/support/home#/case/?displayId=%s",
+	PartitionChina:  "https://console.amazonaws.cn/support/home#/case/?displayId=%s",
+}
+
+var (
+	ErrAccountNotFound      = errors.New("account is not configured")
+	ErrAccountMisconfigured = errors.New("account has neither role arn nor access key")
+	// ErrNoSupportPlan is a SubscriptionRequiredException, the Support API
+	// needs a Business, Enterprise On-Ramp or Enterprise support plan
+	ErrNoSupportPlan = errors.New("account has no support plan")
+)
+
+// AccountError tells which account key could not be used.
+type AccountError struct {
+	AccountKey string
+	Err        error
+}
+
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("account %q: %v", e.AccountKey, e.Err)
+}
+
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+// supportErr wraps a SubscriptionRequiredException of the account into an
+// AccountError with ErrNoSupportPlan, other errors are returned as is.
+func supportErr(key string, err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "SubscriptionRequiredException" {
+		return &AccountError{AccountKey: key, Err: ErrNoSupportPlan}
+	}
+	return err
+}
+
+// supportClientKey holds everything that ends up in the credentials, so a
+// changed account config gets a new client.
+type supportClientKey struct {
+	roleARN     string
+	region      string
+	externalID  string
+	sessionName string
+	sessionTags string
+	accessKeyID string
+}
+
+// supportClients keeps one client per assumed role, the credentials cache
+// inside only calls AssumeRole again when the session is about to expire.
+var (
+	supportClients   = map[supportClientKey]*support.Client{}
+	supportClientsMu sync.Mutex
+	baseConfigs      = map[string]aws.Config{}
+	baseConfigsMu    sync.Mutex
+)
+
+func getAccount(key string) (*config.Account, error) {
+	a, ok := config.Get().Accounts[key]
+	if !ok || a == nil {
+		return nil, &AccountError{AccountKey: key, Err: ErrAccountNotFound}
+	}
+	if a.RoleARN == "" && a.AccessKeyID == "" {
+		return nil, &AccountError{AccountKey: key, Err: ErrAccountMisconfigured}
+	}
+	return a, nil
+}
+
+// GetAccountPartition returns the partition of the account, set explicitly,
+// read from the role ARN or, for old configs, taken from SUPPORT_REGION.
+func GetAccountPartition(a *config.Account) string {
+	if a.Partition != "" {
+		return a.Partition
+	}
+	if parsed, err := arn.Parse(a.RoleARN); err == nil {
+		return parsed.Partition
+	}
+	if os.Getenv("SUPPORT_REGION") == "cn" {
+		return PartitionChina
+	}
+	return PartitionGlobal
+}
+
+func GetAccountRegion(a *config.Account) string {
+	if a.Region != "" {
+		return a.Region
+	}
+	if region, ok := partitionRegion[GetAccountPartition(a)]; ok {
+		return region
+	}
+	return getRegion()
+}
+
+// GetAccountName returns the display name of the account, or its ID.
+func GetAccountName(key string, a *config.Account) string {
+	if a.Name != "" {
+		return a.Name
+	}
+	if a.RoleARN != "" {
+		return GetAccountIdFromRoleARN(a.RoleARN)
+	}
+	return key
+}
+
+func GetCaseURL(a *config.Account, displayID string) string {
+	tpl, ok := partitionCaseURL[GetAccountPartition(a)]
+	if !ok {
+		tpl = partitionCaseURL[PartitionGlobal]
+	}
+	return fmt.Sprintf(tpl, displayID)
+}
+
+func GetSupportClient(c *Case) (*support.Client, error) {
+	a, err := getAccount(c.AccountKey)
+	if err != nil {
+		logrus.Errorf("failed to get account %v", err)
+		return nil, err
+	}
+	region := GetAccountRegion(a)
+	key := supportClientKey{
+		roleARN:     a.RoleARN,
+		region:      region,
+		externalID:  a.ExternalID,
+		sessionName: a.SessionName,
+		sessionTags: joinTags(a.SessionTags),
+		accessKeyID: a.AccessKeyID,
+	}
+
+	supportClientsMu.Lock()
+	defer supportClientsMu.Unlock()
+	if client, ok := supportClients[key]; ok {
+		return client, nil
+	}
+
+	cfg, err := getBaseConfig(region)
+	if err != nil {
+		return nil, err
+	}
+	if a.AccessKeyID != "" {
+		// the lambda role can not assume roles in another partition, such
+		// accounts bring their own keys to call STS with
+		cfg.Credentials = aws.NewCredentialsCache(
+			credentials.NewStaticCredentialsProvider(a.AccessKeyID, a.SecretAccessKey, ""))
+	}
+	if a.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), a.RoleARN,
+			func(o *stscreds.AssumeRoleOptions) {
+				if a.ExternalID != "" {
+					o.ExternalID = aws.String(a.ExternalID)
+				}
+				if a.SessionName != "" {
+					o.RoleSessionName = a.SessionName
+				}
+				for k, v := range a.SessionTags {
+					o.Tags = append(o.Tags, ststypes.Tag{Key: aws.String(k), Value: aws.String(v)})
+				}
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	logrus.Infof("Support Endpoint Region: %s, account %s", region, c.AccountKey)
+	client := support.NewFromConfig(cfg)
+	supportClients[key] = client
+	return client, nil
+}
+
+// getBaseConfig has its own lock, it is called with supportClientsMu held
+// and from the account sync.
+func getBaseConfig(region string) (aws.Config, error) {
+	baseConfigsMu.Lock()
+	defer baseConfigsMu.Unlock()
+	if cfg, ok := baseConfigs[region]; ok {
+		return cfg, nil
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(region))
+	if err != nil {
+		logrus.Printf("Couldn't load default configuration. %v\n", err)
+		return aws.Config{}, err
+	}
+	baseConfigs[region] = cfg
+	return cfg, nil
+}
+
+func joinTags(tags map[string]string) string {
+	kv := make([]string, 0, len(tags))
+	for k, v := range tags {
+		kv = append(kv, k+"="+v)
+	}
+	sort.Strings(kv)
+	return strings.Join(kv, ",")
+}
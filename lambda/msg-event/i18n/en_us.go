@@ -0,0 +1,240 @@
+package i18n
+
+var enUS = map[string]string{
+	// roles and permissions
+	"role.none":              "None",
+	"role.viewer":            "Viewer",
+	"role.submitter":         "Submitter",
+	"role.approver":          "Approver",
+	"role.admin":             "Admin",
+	"permission.denied":      "You are not allowed to do this",
+	"permission.denied_role": "%s (requires the %s role)",
+	// general
+	"usage":       "To open an AWS support case: @bot SUBJECT <title>\nYou can also put “Title: ”, “Description: ”, “Account: ”, “Service: ” and “Severity: ” lines after SUBJECT to fill the case in one message, the case is created after you confirm the card\nFORM [fill in the case on a form card and submit it at once]\nTITLE [the subject of the case]\nDESCRIPTION [the details of the case] [when it started and the time zone / resource IDs and regions / what happens / the impact on your business / contact details]\n\nACCOUNT [the AWS account of the resources]\nSEVERITY [low - 24 hours normal - 12 hours high - 4 hours urgent - 1 hour critical - 15 minutes]\nISSUE_TYPE [technical/account-and-billing/service-limit-increase]\nSERVICE [a keyword to search the service]\nCATEGORY [a category of the chosen service]\nQUOTA REGION REQUESTED_VALUE [the quota, region and requested value of a limit increase case]\nCC [email,email replaces the CC list, +email adds, -email removes; only adding in a case group]\nLANGUAGE [zh/ja/ko/en the language AWS support replies in]\nLOCALE [zh-CN/en-US the language of the bot]\n\nCase updates: [post in the case group the bot creates to reply to the case]",
+	"comment.ack": "Your reply has been received",
+	// service and category
+	"service.limit_case":        "For a service limit increase case choose the service of the quota instead",
+	"service.unknown":           "Unknown service %s, please choose again",
+	"service.no_match":          "No service matches %s",
+	"service.choose_first":      "Please choose the service first",
+	"service.catalog_failed":    "Failed to get the AWS services, please retry later: %v",
+	"category.unknown":          "Service %s has no category %s",
+	"card.category":             "**Category**",
+	"card.category.placeholder": "Choose the category",
+	"cmd.service.syntax":        "[keyword]",
+	"cmd.service.desc":          "Search and choose the service of the issue",
+	"cmd.category.syntax":       "<category>",
+	"cmd.category.desc":         "Choose a category of the chosen service",
+	// cases
+	"case.not_opened":     "You have not opened a case yet, please follow the usage to open one",
+	"case.card_not_found": "The case card was not found",
+	"case.incomplete":     "The case is missing required fields. Send HELP to see the usage",
+	"comment.latest":      "Latest reply from %s (%s)",
+	"cmd.comment.desc":    "Other messages in a case group are sent to AWS as replies to the case",
+	// help
+	"list.separator": ", ",
+	"help.title":     "Bot commands",
+	"help.intro":     "Your role: %s\nIn a group chat @ the bot first. Separate the command and its arguments with a space, <> marks a required argument and [] an optional one.",
+	"help.aliases":   " (also %s)",
+	"help.role":      " [%s]",
+	"cmd.help.desc":  "Show the commands you can use",
+	// commands
+	"cmd.open_case.syntax":       "<subject>",
+	"cmd.open_case.desc":         "Send the case card; put \"Title: \", \"Description: \", \"Account: \", \"Service: \" and \"Severity: \" lines below to fill the whole case at once",
+	"cmd.case_form.desc":         "Send a form card that opens the case once submitted",
+	"cmd.title.syntax":           "<subject>",
+	"cmd.title.desc":             "Change the subject of the case",
+	"cmd.content.syntax":         "<description>",
+	"cmd.content.desc":           "Fill in the description: when it started and the time zone, resource IDs and regions, what happens, the impact on your business, contacts",
+	"cmd.account.syntax":         "<account>",
+	"cmd.account.desc":           "Choose the AWS account of the case",
+	"cmd.issue_type.syntax":      "<Technical|Account and billing|Service limit increase>",
+	"cmd.issue_type.desc":        "Choose the issue type",
+	"cmd.quota.syntax":           "<quota name>",
+	"cmd.quota.desc":             "Fill in the quota of a service limit increase case",
+	"cmd.region.syntax":          "<region, e.g. us-east-1>",
+	"cmd.region.desc":            "Fill in the region of a service limit increase case",
+	"cmd.requested_value.syntax": "<value>",
+	"cmd.requested_value.desc":   "Fill in the requested value of a service limit increase case",
+	"cmd.severity.syntax":        "<low|normal|high|urgent|critical>",
+	"cmd.severity.desc":          "Choose the severity, only the levels of the support plan of the account",
+	"cmd.language.syntax":        "<zh|ja|ko|en>",
+	"cmd.language.desc":          "Choose the language AWS support replies in",
+	"cmd.cc.syntax":              "<email,email|+email|-email>",
+	"cmd.cc.desc":                "Change the CC emails, only adding in a case group",
+	"cmd.confirm.desc":           "Confirm and submit a case filled in one message",
+	"cmd.approval.desc":          "The approve and reject buttons of the approval card",
+	"cmd.history.syntax":         "<days>",
+	"cmd.history.desc":           "List the cases of the past n days",
+	"cmd.q.syntax":               "<question>",
+	"cmd.q.desc":                 "Ask Amazon Q about AWS and its best practices",
+	"cmd.whitelist_add.syntax":   "<email,phone,od-department ID,oc_chat ID>",
+	"cmd.whitelist_add.desc":     "Add users, departments or chats to the whitelist",
+	"cmd.whitelist_del.syntax":   "<email,phone,od-department ID,oc_chat ID>",
+	"cmd.whitelist_del.desc":     "Remove users, departments or chats from the whitelist",
+	"cmd.whitelist.syntax":       "[page]",
+	"cmd.whitelist.desc":         "Show the whitelist card, roles can be changed and entries removed on it",
+	"cmd.set_admin.syntax":       "<email,phone>",
+	"cmd.set_admin.desc":         "Make users admins",
+	"cmd.set_role.syntax":        "<viewer|submitter|approver|admin> <email,phone>",
+	"cmd.set_role.desc":          "Set the role of users",
+	"cmd.whitelist_import.desc":  "Upload a csv file in the chat with the bot to import the whitelist",
+	"cmd.whitelist_export.desc":  "Export the whitelist as a csv file",
+	// issue types and limit increase
+	"issue_type.technical":        "Technical",
+	"issue_type.billing":          "Account and billing",
+	"issue_type.limit":            "Service limit increase",
+	"issue_type.unknown":          "Unknown issue type %s",
+	"limit.not_limit_case":        "Please choose the service limit increase issue type first",
+	"limit.quota_usage":           "Usage: QUOTA <quota name>",
+	"limit.invalid_region":        "Invalid region %s, e.g. us-east-1",
+	"limit.invalid_value":         "Invalid requested value %s, please enter a positive number",
+	"limit.not_supported":         "The account does not support service limit increase cases",
+	"card.no_service_selector":    "The card template has no service selector",
+	"card.issue_type":             "**Issue type**",
+	"card.issue_type.placeholder": "Choose the issue type",
+	"card.limit_service":          "**Service of the quota**",
+	"card.unfilled":               "Not filled in",
+	"card.limit":                  "**Quota:** %s\n**Region:** %s\n**Requested value:** %s\nSend \"QUOTA <quota name>\", \"REGION us-east-1\" and \"REQUESTED_VALUE <value>\" to fill them in",
+	// approval, the notices are raw json text messages, hence the escaped quotes
+	"approval.title":           "Case approval",
+	"approval.card":            "**Submitter:** <at id=%s></at>\n**Subject:** %s\n**Account:** %s\n**Issue type:** %s\n**Service:** %s/%s\n**Severity:** %s\n**Description:** %s",
+	"approval.approve":         "Approve",
+	"approval.reject":          "Reject",
+	"approval.expired":         "The draft was changed or handled, this approval has expired",
+	"approval.no_approver":     "The case needs approval but no approver is configured, please contact an admin",
+	"approval.send_failed":     "Failed to send the approval request, please retry",
+	"approval.requested":       "The severity of the case is %s, it is submitted once approved. The approvers have been asked",
+	"approval.notify_approved": "The case was approved by <at user_id=\\\"%s\\\"></at> and is being created",
+	"approval.notify_rejected": "The case was rejected by <at user_id=\\\"%s\\\"></at>, please change it and open it again",
+	"approval.result_approved": "**%s** was approved by <at id=%s></at>",
+	"approval.result_rejected": "**%s** was rejected by <at id=%s></at>",
+	// cc and language
+	"cc.usage":                  "Usage: CC email,email (replaces the list), CC +email (adds), CC -email (removes)",
+	"cc.remove_opened":          "CC emails can not be removed from an opened case",
+	"cc.already":                "These emails are already CCed",
+	"cc.too_many_added":         "At most %d emails can be added at once",
+	"cc.too_many":               "At most %d emails can be CCed",
+	"cc.invalid":                "Invalid email %s",
+	"cc.added":                  "CCed %s",
+	"cc.none":                   "None",
+	"card.cc":                   "**CC:** %s\nSend \"CC +email\" to add and \"CC -email\" to remove",
+	"language.unsupported":      "Unsupported language %s, choose zh, ja, ko or en",
+	"card.language":             "**Case language**",
+	"card.language.placeholder": "Choose the case language",
+	// case template
+	"account.none":                  "No AWS account is available to you, please contact an admin",
+	"field.title":                   "Title",
+	"field.content":                 "Description",
+	"field.account":                 "Account",
+	"field.service":                 "Service",
+	"field.category":                "Category",
+	"field.severity":                "Severity",
+	"template.incomplete":           "The case is incomplete, please fill in: %s",
+	"template.missing":              "%s is missing",
+	"template.severity_unsupported": "The support plan of the account does not offer severity %s",
+	"template.problems":             "Please correct the case and send it again:\n%s",
+	"template.account_unknown":      "Account %s does not exist or you may not use it",
+	"template.service_unknown":      "Service %s does not exist or matches several services, please use the service code",
+	"card.confirm":                  "Confirm and submit",
+	// severity, account and search
+	"severity.unsupported":    "The support plan of the account does not offer severity %s, please choose again",
+	"severity.failed":         "Failed to get the severities, please retry later: %v",
+	"account.no_support_plan": "Account %s has no support plan and can not open cases through the Support API, please ask an admin to subscribe to Business support or above",
+	"search.header":           "Case ID\\t\\t\\t Account\\t\\t\\t Created\\t\\t\\t\\t Status\\t\\t Subject \\n",
+	// case form
+	"form.title":                "Open a case",
+	"form.title.placeholder":    "The subject of the case",
+	"form.content.placeholder":  "What happens, resource IDs and regions, the impact on your business, contacts",
+	"form.account.placeholder":  "Choose the account",
+	"form.service.placeholder":  "Choose the service",
+	"form.severity.placeholder": "Choose the severity",
+	"form.started.placeholder":  "When the issue started (optional)",
+	"form.submit":               "Submit",
+	"form.note":                 "Services and severities are listed for the first usable account and checked against the chosen account on submit.\nFill in the category name or code when the service has several, use SUBJECT for another issue type.",
+	"form.required":             "Please fill in the title, description, account, service and severity",
+	"form.started_at":           "Issue started at: %s\n\n%s",
+	"form.pending_approval":     "Case \"%s\" was submitted and waits for approval",
+	// whitelist and roles
+	"whitelist.add_failed":            "Failed to add to the whitelist, please retry",
+	"whitelist.add_department_failed": "Failed to add the departments to the whitelist, please retry",
+	"whitelist.add_chat_failed":       "Failed to add the chats to the whitelist, please retry",
+	"whitelist.added":                 "Added to the whitelist",
+	"whitelist.del_failed":            "Failed to remove from the whitelist, please retry",
+	"whitelist.del_department_failed": "Failed to remove the departments from the whitelist, please retry",
+	"whitelist.del_chat_failed":       "Failed to remove the chats from the whitelist, please retry",
+	"whitelist.deleted":               "Removed from the whitelist",
+	"whitelist.unknown_users":         "Could not find the users of %v, please check the phone numbers or emails",
+	"whitelist.admin_failed":          "Failed to add the admins, please retry",
+	"whitelist.admin_set":             "Admins added",
+	"whitelist.title":                 "Whitelist (%d users)",
+	"whitelist.remove":                "Remove",
+	"whitelist.promote":               "Make admin",
+	"whitelist.prev":                  "Previous",
+	"whitelist.next":                  "Next",
+	"whitelist.page":                  "Page %d/%d",
+	"whitelist.department":            "Department: %s (%s)",
+	"whitelist.chat":                  "Chat: %s (%s)",
+	"whitelist.gone":                  "The user is no longer in the whitelist",
+	"whitelist.remove_self":           "You can not remove yourself",
+	"whitelist.removed":               "Removed %s",
+	"whitelist.promoted":              "%s is now an admin",
+	"whitelist.action_failed":         "The action failed, please retry",
+	"role.usage":                      "Usage: SET_ROLE viewer|submitter|approver|admin email or phone,email or phone",
+	"role.set_failed":                 "Failed to set the role, please retry",
+	"role.set":                        "Role set to %s",
+	// whitelist csv
+	"csv.not_csv":         "Send a CSV file to import the whitelist, the columns are: email or phone,role,accounts (separated by ;)",
+	"csv.parse_failed":    "Failed to parse the CSV file: %v",
+	"csv.row_ok":          "OK",
+	"csv.imported":        "Whitelist imported, %d rows succeeded and %d rows failed",
+	"csv.duplicate":       "Duplicate user",
+	"csv.unknown_role":    "Unknown role %s",
+	"csv.unknown_account": "Unknown account %s",
+	"csv.unknown_user":    "Could not find the user, please check the phone number or email",
+	"csv.add_failed":      "Failed to add to the whitelist",
+	"csv.accounts_failed": "Failed to set the accounts",
+	// account sync and commands, text messages are raw json, no plain double quotes
+	"sync.done":        "Accounts synced",
+	"sync.added":       "Added accounts: %s",
+	"sync.removed":     "Removed accounts: %s",
+	"sync.unassumable": "The support role of these accounts can not be assumed:",
+	"sync.assumable":   "The support role of these accounts can be assumed again: %s",
+	"list.or":          " or ",
+	"command.suggest":  "Unknown command “%s”, did you mean %s?",
+	// locale
+	"locale.usage":      "Usage: LOCALE zh-CN|en-US",
+	"locale.failed":     "Failed to set the language, please retry",
+	"locale.set":        "The bot replies in English from now on",
+	"cmd.locale.syntax": "<zh-CN|en-US>",
+	"cmd.locale.desc":   "Set the language the bot replies in, your own in the chat with the bot, the chat's in a group (admins only)",
+	// resolve
+	"resolve.not_case":       "Resolve a case in its case group",
+	"resolve.already":        "Case %s is already resolved",
+	"resolve.title":          "Resolve case",
+	"resolve.confirm":        "Resolve case **%s** %s? The AWS case is closed, a reply in this group reopens it",
+	"resolve.confirm_button": "Resolve",
+	"resolve.cancel_button":  "Cancel",
+	"resolve.canceled":       "Resolving the case was canceled",
+	"resolve.done":           "Case **%s** was resolved by <at id=%s></at>",
+	"resolve.button":         "Resolve case",
+	"resolve.summary_title":  "Case resolved",
+	"resolve.summary":        "**Case ID:** %s\n**Subject:** %s\n**Status:** %s → %s\n**Resolved by:** <at id=%s></at>\n**Created:** %s\n**Case link:** [%s](%s)",
+	"cmd.resolve.desc":       "Resolve the case of the group, the AWS case is closed after a confirmation and a summary is posted",
+	// case status
+	"aws_status.unknown":                   "unknown",
+	"aws_status.opened":                    "Opened",
+	"aws_status.unassigned":                "Unassigned",
+	"aws_status.work-in-progress":          "Work in progress",
+	"aws_status.pending-customer-action":   "Pending customer action",
+	"aws_status.customer-action-completed": "Customer action completed",
+	"aws_status.reopened":                  "Reopened",
+	"aws_status.resolved":                  "Resolved",
+	"case_status.title":                    "Case status: %s",
+	"case_status.notice":                   "**Case ID:** %s\n**Status:** %s → %s\n**Time:** %s",
+	"case_status.pending_customer":         "<at id=%s></at> The AWS engineer is waiting for your reply, reply in this group to continue the case",
+	"form.category.placeholder":            "The category of the service, may be left empty when the service has a single one",
+	"form.category_required":               "Please fill in the category of the chosen service: %s",
+	"form.created":                         "Case \"%s\" was created",
+	"account.choose_first":                 "Please choose the account first",
+}
@@ -0,0 +1,129 @@
+// Package i18n is the message catalog of the bot. Replies and cards look
+// their texts up by key in the bundle of the locale of the chat or user.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	EnUS Locale = "en-US"
+	// Default is the locale of users and chats without one, and of the logs
+	Default = ZhCN
+)
+
+var bundles = map[Locale]map[string]string{
+	ZhCN: zhCN,
+	EnUS: enUS,
+}
+
+// Locales returns the locales with a bundle, the default first.
+func Locales() []Locale {
+	return []Locale{ZhCN, EnUS}
+}
+
+// overrides are the messages of the bot config by locale and key, they win
+// over the bundles
+var overrides atomic.Pointer[map[Locale]map[string]string]
+
+// SetOverrides replaces the messages of the bot config, locales are parsed
+// with Parse and unknown locales are dropped.
+func SetOverrides(messages map[string]map[string]string) {
+	m := map[Locale]map[string]string{}
+	for s, msgs := range messages {
+		if l, ok := Parse(s); ok {
+			if m[l] == nil {
+				m[l] = map[string]string{}
+			}
+			for k, v := range msgs {
+				m[l][k] = v
+			}
+		}
+	}
+	overrides.Store(&m)
+}
+
+// Parse accepts zh-CN, en-US and their short or underscore forms, case is
+// ignored.
+func Parse(s string) (Locale, bool) {
+	s = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", "-"))
+	switch {
+	case s == "zh" || strings.HasPrefix(s, "zh-"):
+		return ZhCN, true
+	case s == "en" || strings.HasPrefix(s, "en-"):
+		return EnUS, true
+	}
+	return "", false
+}
+
+// T returns the message of the key in the locale formatted with args. A
+// message missing in the locale falls back to the default locale, then to
+// the key itself.
+func T(l Locale, key string, args ...interface{}) string {
+	msg, ok := lookup(l, key)
+	if !ok {
+		msg, ok = lookup(Default, key)
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, localizeArgs(l, args)...)
+}
+
+func lookup(l Locale, key string) (string, bool) {
+	if m := overrides.Load(); m != nil {
+		if msg, ok := (*m)[l][key]; ok && msg != "" {
+			return msg, true
+		}
+	}
+	msg, ok := bundles[l][key]
+	return msg, ok
+}
+
+// localizeArgs turns the errors among the args into their message in the
+// locale.
+func localizeArgs(l Locale, args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i, a := range args {
+		if err, ok := a.(error); ok {
+			a = Message(l, err)
+		}
+		out[i] = a
+	}
+	return out
+}
+
+// Error is an error whose text is looked up in the locale of the user it is
+// shown to, its Error is the text in the default locale.
+type Error struct {
+	Key  string
+	Args []interface{}
+}
+
+// Errorf returns an Error of the message key formatted with args.
+func Errorf(key string, args ...interface{}) error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return T(Default, e.Key, e.Args...)
+}
+
+// Message returns the text of the error in the locale, errors that are not
+// an Error keep their own text.
+func Message(l Locale, err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return T(l, e.Key, e.Args...)
+	}
+	return err.Error()
+}
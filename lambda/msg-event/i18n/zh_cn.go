@@ -0,0 +1,240 @@
+package i18n
+
+var zhCN = map[string]string{
+	// roles and permissions
+	"role.none":              "无",
+	"role.viewer":            "查看者",
+	"role.submitter":         "提交者",
+	"role.approver":          "审批者",
+	"role.admin":             "管理员",
+	"permission.denied":      "你没有权限执行该操作",
+	"permission.denied_role": "%s（需要%s权限）",
+	// general
+	"usage":       "开AWS支持案例方法：@机器人 开工单 问题\n也可以在“开工单”后换行，每行填写“问题: ”“内容: ”“账户: ”“服务: ”“响应速度: ”一次提交，确认卡片后创建工单\n表单开工单 [在表单卡片中填写工单并直接提交]\n问题 [工单的题目]\n内容 [工单内容] [包括：问题发生的时间及时区/涉及的资源ID及region/发生问题的现象/该问题对业务造成的影响/联系人及联系方式等信息]\n\n账号 [问题涉及资源属于的AWS账户]\n响应速度 [low - 24小时 normal - 12小时 high - 4小时 urgent - 1小时 critical - 15分钟) ]\n类型 [技术支持/账户和账单/服务配额提升]\n服务 [关键字，搜索问题涉及的服务]\n类别 [所选服务下的问题类别]\n配额 区域 申请值 [服务配额提升工单的配额名称，区域及申请的数量]\n抄送 [邮箱,邮箱 替换抄送列表，+邮箱 添加，-邮箱 移除；工单群里只能添加]\n语言 [zh/ja/ko/en 工单的联系语言]\n界面语言 [zh-CN/en-US 机器人回复的语言]\n\n案例更新：[在机器人创建的新工单群里发言提交工单更新]",
+	"comment.ack": "回复已经收到",
+	// service and category
+	"service.limit_case":        "服务配额提升工单请在配额所属服务中选择服务",
+	"service.unknown":           "未知的服务 %s，请重新选择",
+	"service.no_match":          "没有找到和 %s 匹配的服务",
+	"service.choose_first":      "请先选择服务",
+	"service.catalog_failed":    "获取AWS服务列表失败，请稍后重试: %v",
+	"category.unknown":          "服务 %s 没有类别 %s",
+	"card.category":             "**类别**",
+	"card.category.placeholder": "请选择问题类别",
+	"cmd.service.syntax":        "[关键字]",
+	"cmd.service.desc":          "搜索并选择问题涉及的服务",
+	"cmd.category.syntax":       "<类别>",
+	"cmd.category.desc":         "选择所选服务下的问题类别",
+	// cases
+	"case.not_opened":     "您还没有开工单，请按照说明开工单",
+	"case.card_not_found": "没有找到工单卡片",
+	"case.incomplete":     "工单创建必要内容缺失。请输入帮助关键字获取使用信息",
+	"comment.latest":      "来自%s的最新回复(%s)",
+	"cmd.comment.desc":    "工单群里的其他消息作为工单回复发送给AWS",
+	// help
+	"list.separator": "、",
+	"help.title":     "机器人命令",
+	"help.intro":     "你的角色：%s\n群聊中请先@机器人，命令和参数之间用空格分隔，<>是必填参数，[]是可选参数。",
+	"help.aliases":   "（也可以用 %s）",
+	"help.role":      "〔%s〕",
+	"cmd.help.desc":  "显示可以使用的命令",
+	// commands
+	"cmd.open_case.syntax":       "<工单题目>",
+	"cmd.open_case.desc":         "发送开工单小卡片；换行后每行填写“问题: ”“内容: ”“账户: ”“服务: ”“响应速度: ”可以一次填好工单",
+	"cmd.case_form.desc":         "发送表单卡片，填写后直接提交工单",
+	"cmd.title.syntax":           "<工单题目>",
+	"cmd.title.desc":             "修改工单题目",
+	"cmd.content.syntax":         "<工单内容>",
+	"cmd.content.desc":           "填写工单内容：问题发生的时间及时区，涉及的资源ID及region，问题现象，对业务的影响，联系人等",
+	"cmd.account.syntax":         "<账户>",
+	"cmd.account.desc":           "选择工单账户",
+	"cmd.issue_type.syntax":      "<技术支持|账户和账单|服务配额提升>",
+	"cmd.issue_type.desc":        "选择问题类型",
+	"cmd.quota.syntax":           "<配额名称>",
+	"cmd.quota.desc":             "填写服务配额提升工单的配额",
+	"cmd.region.syntax":          "<区域，例如us-east-1>",
+	"cmd.region.desc":            "填写服务配额提升工单的区域",
+	"cmd.requested_value.syntax": "<数量>",
+	"cmd.requested_value.desc":   "填写服务配额提升工单申请的数量",
+	"cmd.severity.syntax":        "<low|normal|high|urgent|critical>",
+	"cmd.severity.desc":          "选择响应速度，只能选择账户支持计划允许的级别",
+	"cmd.language.syntax":        "<zh|ja|ko|en>",
+	"cmd.language.desc":          "选择工单的联系语言",
+	"cmd.cc.syntax":              "<邮箱,邮箱|+邮箱|-邮箱>",
+	"cmd.cc.desc":                "修改抄送邮箱，工单群里只能添加",
+	"cmd.confirm.desc":           "确认一次填好的工单并提交",
+	"cmd.approval.desc":          "审批卡片的批准和拒绝按钮",
+	"cmd.history.syntax":         "<天数>",
+	"cmd.history.desc":           "查询过去n天的工单",
+	"cmd.q.syntax":               "<问题>",
+	"cmd.q.desc":                 "向AmazonQ询问AWS知识和最佳实践",
+	"cmd.whitelist_add.syntax":   "<邮箱,电话,od-部门ID,oc_群ID>",
+	"cmd.whitelist_add.desc":     "把用户，部门或群加入白名单",
+	"cmd.whitelist_del.syntax":   "<邮箱,电话,od-部门ID,oc_群ID>",
+	"cmd.whitelist_del.desc":     "把用户，部门或群移出白名单",
+	"cmd.whitelist.syntax":       "[页码]",
+	"cmd.whitelist.desc":         "查看白名单卡片，可以在卡片中修改角色或移除",
+	"cmd.set_admin.syntax":       "<邮箱,电话>",
+	"cmd.set_admin.desc":         "把用户设置为管理员",
+	"cmd.set_role.syntax":        "<viewer|submitter|approver|admin> <邮箱,电话>",
+	"cmd.set_role.desc":          "设置用户的角色",
+	"cmd.whitelist_import.desc":  "在和机器人的单聊中上传csv文件导入白名单",
+	"cmd.whitelist_export.desc":  "导出白名单csv文件",
+	// issue types and limit increase
+	"issue_type.technical":        "技术支持",
+	"issue_type.billing":          "账户和账单",
+	"issue_type.limit":            "服务配额提升",
+	"issue_type.unknown":          "未知的问题类型 %s",
+	"limit.not_limit_case":        "请先选择服务配额提升问题类型",
+	"limit.quota_usage":           "格式: 配额 配额名称",
+	"limit.invalid_region":        "无效的区域 %s，格式例如 us-east-1",
+	"limit.invalid_value":         "无效的申请值 %s，请输入正数",
+	"limit.not_supported":         "当前账户不支持服务配额提升工单",
+	"card.no_service_selector":    "卡片模板中没有服务选择",
+	"card.issue_type":             "**问题类型**",
+	"card.issue_type.placeholder": "请选择问题类型",
+	"card.limit_service":          "**配额所属服务**",
+	"card.unfilled":               "未填写",
+	"card.limit":                  "**配额：** %s\n**区域：** %s\n**申请值：** %s\n发送“配额 配额名称”“区域 us-east-1”“申请值 数量”填写",
+	// approval, the notices are raw json text messages, hence the escaped quotes
+	"approval.title":           "工单审批",
+	"approval.card":            "**提交人:** <at id=%s></at>\n**问题:** %s\n**账户:** %s\n**问题类型:** %s\n**服务:** %s/%s\n**响应速度:** %s\n**内容:** %s",
+	"approval.approve":         "批准",
+	"approval.reject":          "拒绝",
+	"approval.expired":         "工单草稿已修改或已处理，本审批已失效",
+	"approval.no_approver":     "该工单需要审批，但没有配置审批人，请联系管理员",
+	"approval.send_failed":     "审批请求发送失败，请重试",
+	"approval.requested":       "工单响应速度为%s，需要审批后才会提交，已发送给审批人",
+	"approval.notify_approved": "工单已由 <at user_id=\\\"%s\\\"></at> 批准，正在创建",
+	"approval.notify_rejected": "工单已被 <at user_id=\\\"%s\\\"></at> 拒绝，请修改后重新开工单",
+	"approval.result_approved": "**%s** 已由 <at id=%s></at> 批准",
+	"approval.result_rejected": "**%s** 已由 <at id=%s></at> 拒绝",
+	// cc and language
+	"cc.usage":                  "格式: 抄送 邮箱,邮箱（替换抄送列表），抄送 +邮箱（添加），抄送 -邮箱（移除）",
+	"cc.remove_opened":          "已创建的工单不能移除抄送邮箱",
+	"cc.already":                "这些邮箱已经在抄送列表中",
+	"cc.too_many_added":         "一次最多抄送%d个邮箱",
+	"cc.too_many":               "最多抄送%d个邮箱",
+	"cc.invalid":                "无效的邮箱 %s",
+	"cc.added":                  "已抄送 %s",
+	"cc.none":                   "无",
+	"card.cc":                   "**抄送：** %s\n发送“抄送 +邮箱”添加，“抄送 -邮箱”移除",
+	"language.unsupported":      "不支持的语言 %s，可以选择 zh、ja、ko 或 en",
+	"card.language":             "**工单语言**",
+	"card.language.placeholder": "请选择工单语言",
+	// case template
+	"account.none":                  "没有可以使用的AWS账户，请联系管理员",
+	"field.title":                   "问题",
+	"field.content":                 "内容",
+	"field.account":                 "账户",
+	"field.service":                 "服务",
+	"field.category":                "类别",
+	"field.severity":                "响应速度",
+	"template.incomplete":           "工单信息不完整，请补充: %s",
+	"template.missing":              "缺少 %s",
+	"template.severity_unsupported": "当前账户的支持计划不支持响应速度 %s",
+	"template.problems":             "工单模板有以下问题，请修改后重新发送:\n%s",
+	"template.account_unknown":      "账户 %s 不存在或没有使用权限",
+	"template.service_unknown":      "服务 %s 不存在或匹配多个服务，请填写服务代码",
+	"card.confirm":                  "确认提交",
+	// severity, account and search
+	"severity.unsupported":    "当前账户的支持计划不支持响应速度 %s，请重新选择",
+	"severity.failed":         "获取响应速度失败，请稍后重试: %v",
+	"account.no_support_plan": "账户 %s 没有开通支持计划，无法通过Support API提交工单，请联系管理员开通Business及以上级别的支持计划",
+	"search.header":           "案例号\\t\\t\\t 账号\\t\\t\\t 创建时间\\t\\t\\t\\t 案例状态\\t\\t 题目 \\n",
+	// case form
+	"form.title":                "开工单",
+	"form.title.placeholder":    "工单的题目",
+	"form.content.placeholder":  "问题的现象，涉及的资源ID及region，对业务的影响，联系人及联系方式等",
+	"form.account.placeholder":  "请选择账户",
+	"form.service.placeholder":  "请选择服务",
+	"form.severity.placeholder": "请选择响应速度",
+	"form.started.placeholder":  "问题开始时间（可选）",
+	"form.submit":               "提交",
+	"form.note":                 "服务和响应速度按第一个可以使用的账户列出，提交时按所选账户检查。\n服务有多个类别时请填写类别名称或代码，需要其他问题类型时请使用“开工单”。",
+	"form.required":             "请填写问题，内容，账户，服务和响应速度",
+	"form.started_at":           "问题开始时间: %s\n\n%s",
+	"form.pending_approval":     "工单“%s”已提交，等待审批",
+	// whitelist and roles
+	"whitelist.add_failed":            "添加白名单失败，请重试",
+	"whitelist.add_department_failed": "添加部门白名单失败，请重试",
+	"whitelist.add_chat_failed":       "添加群白名单失败，请重试",
+	"whitelist.added":                 "添加白名单成功",
+	"whitelist.del_failed":            "删除白名单失败，请重试",
+	"whitelist.del_department_failed": "删除部门白名单失败，请重试",
+	"whitelist.del_chat_failed":       "删除群白名单失败，请重试",
+	"whitelist.deleted":               "删除白名单成功",
+	"whitelist.unknown_users":         "无法获取 %v 对应的用户id，请核对是否是正确的电话号码或者邮箱",
+	"whitelist.admin_failed":          "添加admin失败，请重试",
+	"whitelist.admin_set":             "添加admin成功",
+	"whitelist.title":                 "白名单 (%d人)",
+	"whitelist.remove":                "移除",
+	"whitelist.promote":               "设为管理员",
+	"whitelist.prev":                  "上一页",
+	"whitelist.next":                  "下一页",
+	"whitelist.page":                  "第 %d/%d 页",
+	"whitelist.department":            "部门: %s (%s)",
+	"whitelist.chat":                  "群: %s (%s)",
+	"whitelist.gone":                  "该用户已不在白名单中",
+	"whitelist.remove_self":           "不能移除自己",
+	"whitelist.removed":               "已移除 %s",
+	"whitelist.promoted":              "已将 %s 设为管理员",
+	"whitelist.action_failed":         "操作失败，请重试",
+	"role.usage":                      "格式: 设置角色 viewer|submitter|approver|admin 邮箱或电话,邮箱或电话",
+	"role.set_failed":                 "设置角色失败，请重试",
+	"role.set":                        "设置%s成功",
+	// whitelist csv
+	"csv.not_csv":         "请直接发送CSV文件导入白名单，列为: 邮箱或电话,角色,账号（多个账号用;分隔）",
+	"csv.parse_failed":    "无法解析CSV文件: %v",
+	"csv.row_ok":          "成功",
+	"csv.imported":        "导入白名单完成，成功%d行，失败%d行",
+	"csv.duplicate":       "重复的用户",
+	"csv.unknown_role":    "未知的角色 %s",
+	"csv.unknown_account": "未知的账号 %s",
+	"csv.unknown_user":    "无法获取对应的用户id，请核对是否是正确的电话号码或者邮箱",
+	"csv.add_failed":      "添加白名单失败",
+	"csv.accounts_failed": "设置账号失败",
+	// account sync and commands, text messages are raw json, no plain double quotes
+	"sync.done":        "账户同步完成",
+	"sync.added":       "新增账户: %s",
+	"sync.removed":     "移除账户: %s",
+	"sync.unassumable": "以下账户无法代入支持角色:",
+	"sync.assumable":   "以下账户恢复可以代入支持角色: %s",
+	"list.or":          "或",
+	"command.suggest":  "未知的命令“%s”，您是不是要输入%s？",
+	// locale
+	"locale.usage":      "格式: 界面语言 zh-CN|en-US",
+	"locale.failed":     "设置界面语言失败，请重试",
+	"locale.set":        "机器人将使用简体中文回复",
+	"cmd.locale.syntax": "<zh-CN|en-US>",
+	"cmd.locale.desc":   "设置机器人回复的语言，单聊中设置自己的，群聊中设置本群的（需要管理员）",
+	// resolve
+	"resolve.not_case":       "请在工单群中结案",
+	"resolve.already":        "工单 %s 已结案",
+	"resolve.title":          "结案确认",
+	"resolve.confirm":        "确认结案工单 **%s** %s？AWS 工单将被关闭，之后在群里回复会重新打开工单",
+	"resolve.confirm_button": "确认结案",
+	"resolve.cancel_button":  "取消",
+	"resolve.canceled":       "已取消结案",
+	"resolve.done":           "工单 **%s** 已由 <at id=%s></at> 结案",
+	"resolve.button":         "结案",
+	"resolve.summary_title":  "工单已结案",
+	"resolve.summary":        "**工单号:** %s\n**问题:** %s\n**状态:** %s → %s\n**结案人:** <at id=%s></at>\n**创建时间:** %s\n**工单链接:** [%s](%s)",
+	"cmd.resolve.desc":       "在工单群中结案，确认后关闭 AWS 工单并在群里发送结案总结",
+	// case status
+	"aws_status.unknown":                   "未知",
+	"aws_status.opened":                    "已提交",
+	"aws_status.unassigned":                "待分配",
+	"aws_status.work-in-progress":          "处理中",
+	"aws_status.pending-customer-action":   "等待客户回复",
+	"aws_status.customer-action-completed": "客户已回复",
+	"aws_status.reopened":                  "已重新打开",
+	"aws_status.resolved":                  "已解决",
+	"case_status.title":                    "工单状态: %s",
+	"case_status.notice":                   "**工单号:** %s\n**状态:** %s → %s\n**时间:** %s",
+	"case_status.pending_customer":         "<at id=%s></at> AWS 工程师正在等待您的回复，请在本群回复以继续处理工单",
+	"form.category.placeholder":            "问题类别，服务只有一个类别时可不填",
+	"form.category_required":               "请填写所选服务的问题类别: %s",
+	"form.created":                         "工单“%s”已创建",
+	"account.choose_first":                 "请先选择账户",
+}
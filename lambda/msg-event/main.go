@@ -2,26 +2,50 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"flag"
+	"msg-event/dao"
 	"msg-event/model/event"
-	"msg-event/model/response"
+	"msg-event/server"
 	"msg-event/services"
+	"net/http"
+	"os"
+	"os/signal"
 	"runtime/debug"
+	"syscall"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/sirupsen/logrus"
 )
 
-func HandleRequest(ctx context.Context, e *event.Msg) (event *response.MsgResponse, err error) {
+const (
+	modeLambda = "lambda"
+	modeHTTP   = "http"
+	modeWS     = "ws"
+)
+
+func HandleRequest(ctx context.Context, raw json.RawMessage) (rsp interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			logrus.Infof("panic is %v", string(debug.Stack()))
 		}
 	}()
-	s, _ := json.Marshal(e)
 
-	logrus.Infof("event is %s", string(s))
+	logrus.Infof("event is %s", string(raw))
 
+	req := &events.APIGatewayProxyRequest{}
+	if err := json.Unmarshal(raw, req); err == nil && req.HTTPMethod != "" {
+		return handleWebhook(ctx, req), nil
+	}
+
+	// events from EventBridge are invoked through IAM and carry no signature
+	e := &event.Envelope{}
+	if err := json.Unmarshal(raw, e); err != nil {
+		logrus.Errorf("failed to unmarshal event %v", err)
+		return nil, nil
+	}
 	r, err := services.Serve(ctx, e)
 	if err != nil {
 		logrus.Errorf("handle err %v", err)
@@ -29,8 +53,63 @@ func HandleRequest(ctx context.Context, e *event.Msg) (event *response.MsgRespon
 	return r, nil
 }
 
+func handleWebhook(ctx context.Context, req *events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	header := http.Header{}
+	for k, v := range req.Headers {
+		header.Set(k, v)
+	}
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			logrus.Errorf("failed to decode body %v", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}
+		}
+		body = b
+	}
+
+	status, rsp := server.Handle(ctx, header, body)
+	if rsp == nil {
+		return events.APIGatewayProxyResponse{StatusCode: status}
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(rsp),
+	}
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
 func main() {
+	mode := flag.String("mode", getEnv("RUN_MODE", modeLambda), "run mode, lambda, http or ws")
+	addr := flag.String("addr", getEnv("HTTP_ADDR", ":8080"), "listen address of the http mode")
+	domain := flag.String("ws-domain", getEnv("WS_DOMAIN", ""), "open platform domain of the ws mode, defaults to BOT_ENDPOINT")
+	flag.Parse()
 
-	lambda.Start(HandleRequest)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
+	switch *mode {
+	case modeHTTP:
+		if err := server.ListenAndServe(ctx, *addr); err != nil {
+			logrus.Fatalf("http server stopped %v", err)
+		}
+	case modeWS:
+		if *domain == "" {
+			*domain = dao.OpenBaseURL()
+		}
+		if err := server.ServeLongConn(ctx, *domain); err != nil {
+			logrus.Fatalf("long connection stopped %v", err)
+		}
+	case modeLambda:
+		lambda.Start(HandleRequest)
+	default:
+		logrus.Fatalf("unknown run mode %s", *mode)
+	}
 }
@@ -2,6 +2,8 @@ package model
 
 type Config struct {
 	WideScreenMode bool `json:"wide_screen_mode"`
+	// UpdateMulti makes a shared card, only those can be updated after sending
+	UpdateMulti bool `json:"update_multi,omitempty"`
 }
 type Text struct {
 	Tag     string `json:"tag"`
@@ -41,13 +43,53 @@ type Href struct {
 	URLVal URLVal `json:"urlVal"`
 }
 type Elements struct {
-	Tag     string `json:"tag"`
-	Text    Text   `json:"text,omitempty"`
-	Extra   Extra  `json:"extra,omitempty"`
-	Content string `json:"content,omitempty"`
-	Href    Href   `json:"href,omitempty"`
+	Tag     string   `json:"tag"`
+	Text    Text     `json:"text,omitempty"`
+	Extra   Extra    `json:"extra,omitempty"`
+	Content string   `json:"content,omitempty"`
+	Href    Href     `json:"href,omitempty"`
+	Actions []Button `json:"actions,omitempty"`
+	// Name and Fields make a form container with tag "form"
+	Name   string      `json:"name,omitempty"`
+	Fields []FormField `json:"elements,omitempty"`
+}
+
+// FormField is an input, a select, a date time picker or the submit button
+// of a form container. The values of the fields are sent back by name in the
+// form_value of the callback of the button with ActionType "form_submit".
+type FormField struct {
+	Tag           string       `json:"tag"`
+	Name          string       `json:"name"`
+	Label         *Text        `json:"label,omitempty"`
+	Placeholder   *Placeholder `json:"placeholder,omitempty"`
+	Required      bool         `json:"required,omitempty"`
+	InputType     string       `json:"input_type,omitempty"`
+	Rows          int          `json:"rows,omitempty"`
+	MaxLength     int          `json:"max_length,omitempty"`
+	Options       []Options    `json:"options,omitempty"`
+	InitialOption string       `json:"initial_option,omitempty"`
+	// Text, Type, ActionType and Value are for buttons
+	Text       *Text             `json:"text,omitempty"`
+	Type       string            `json:"type,omitempty"`
+	ActionType string            `json:"action_type,omitempty"`
+	Value      map[string]string `json:"value,omitempty"`
+}
+
+// Button goes into the Actions of an element with tag "action", Value is
+// sent back in the card callback.
+type Button struct {
+	Tag   string            `json:"tag"`
+	Text  Text              `json:"text"`
+	Type  string            `json:"type,omitempty"`
+	Value map[string]string `json:"value,omitempty"`
 }
 type Card struct {
 	Config   Config     `json:"config"`
+	Header   *Header    `json:"header,omitempty"`
 	Elements []Elements `json:"elements"`
 }
+
+type Header struct {
+	Title    Text   `json:"title"`
+	Template string `json:"template,omitempty"`
+}
@@ -0,0 +1,119 @@
+package event
+
+import "encoding/json"
+
+const (
+	TypeURLVerification = "url_verification"
+	TypeMessageReceive  = "im.message.receive_v1"
+	TypeCardAction      = "card.action.trigger"
+	TypeBotAdded        = "im.chat.member.bot.added_v1"
+	TypeMessageRecalled = "im.message.recalled_v1"
+	// TypeFreshComment and TypeSyncAccounts are sent by our own EventBridge
+	// rules, not by Lark.
+	TypeFreshComment = "fresh_comment"
+	TypeSyncAccounts = "sync_accounts"
+)
+
+// Encrypted is the body Lark sends when an encrypt key is set for the app.
+type Encrypted struct {
+	Encrypt string `json:"encrypt"`
+}
+
+// Envelope is what arrives on the wire. The payload is kept raw and decoded
+// into the typed struct of the route matching EventType.
+type Envelope struct {
+	Schema    string          `json:"schema,omitempty"`
+	Type      string          `json:"type,omitempty"`
+	Challenge string          `json:"challenge,omitempty"`
+	Token     string          `json:"token,omitempty"`
+	Header    Header          `json:"header,omitempty"`
+	Event     json.RawMessage `json:"event,omitempty"`
+	//v1 card callback
+	OpenID     string  `json:"open_id,omitempty"`
+	UserID     string  `json:"user_id,omitempty"`
+	TenantKey  string  `json:"tenant_key,omitempty"`
+	OpenMsgID  string  `json:"open_message_id,omitempty"`
+	OpenChatID string  `json:"open_chat_id,omitempty"`
+	Action     *Action `json:"action,omitempty"`
+}
+
+// EventType returns header.event_type, v1 card callbacks and url_verification
+// have no header and are recognised by their body.
+func (e *Envelope) EventType() string {
+	switch {
+	case e.Header.EventType != "":
+		return e.Header.EventType
+	case e.Type == TypeURLVerification:
+		return TypeURLVerification
+	case e.Action != nil:
+		return TypeCardAction
+	}
+	return ""
+}
+
+// Decode unmarshals the event payload into v.
+func (e *Envelope) Decode(v interface{}) error {
+	if len(e.Event) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Event, v)
+}
+
+// CardAction returns the card.action.trigger payload, the fields of a v1
+// card callback are moved into the v2 layout.
+func (e *Envelope) CardAction() (*CardAction, error) {
+	c := &CardAction{}
+	if e.Header.EventType == TypeCardAction {
+		err := e.Decode(c)
+		return c, err
+	}
+	c.Operator = UserIDs{
+		UserID: e.UserID,
+		OpenID: e.OpenID,
+	}
+	c.Token = e.Token
+	c.Action = e.Action
+	c.Context = CardContext{
+		OpenMsgID:  e.OpenMsgID,
+		OpenChatID: e.OpenChatID,
+	}
+	return c, nil
+}
+
+type UserIDs struct {
+	UnionID   string `json:"union_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	OpenID    string `json:"open_id,omitempty"`
+	TenantKey string `json:"tenant_key,omitempty"`
+}
+
+// CardAction is the payload of card.action.trigger.
+type CardAction struct {
+	Operator UserIDs     `json:"operator"`
+	Token    string      `json:"token"`
+	Action   *Action     `json:"action"`
+	Host     string      `json:"host"`
+	Context  CardContext `json:"context"`
+}
+
+type CardContext struct {
+	URL        string `json:"url,omitempty"`
+	OpenMsgID  string `json:"open_message_id,omitempty"`
+	OpenChatID string `json:"open_chat_id,omitempty"`
+}
+
+// BotAdded is the payload of im.chat.member.bot.added_v1.
+type BotAdded struct {
+	ChatID     string  `json:"chat_id"`
+	OperatorID UserIDs `json:"operator_id"`
+	External   bool    `json:"external"`
+	Name       string  `json:"name"`
+}
+
+// MessageRecalled is the payload of im.message.recalled_v1.
+type MessageRecalled struct {
+	MsgID      string `json:"message_id"`
+	ChatID     string `json:"chat_id"`
+	RecallTime string `json:"recall_time"`
+	RecallType string `json:"recall_type"`
+}
@@ -1,5 +1,7 @@
 package event
 
+import "strings"
+
 type Header struct {
 	EventID    string `json:"event_id,omitempty"`
 	EventType  string `json:"event_type,omitempty"`
@@ -9,6 +11,7 @@ type Header struct {
 	TenantKey  string `json:"tenant_key,omitempty"`
 }
 
+// Event is the payload of im.message.receive_v1.
 type Event struct {
 	Sender  Sender  `json:"sender,omitempty"`
 	Message Message `json:"message,omitempty"`
@@ -57,20 +60,59 @@ type Msg struct {
 	Challenge string `json:"challenge"`
 	Header    Header `json:"header,omitempty"`
 	//card
-	OpenID    string  `json:"open_id"`
-	UserID    string  `json:"user_id"`
-	TenantKey string  `json:"tenant_key"`
-	OpenMsgID string  `json:"open_message_id"`
-	Token     string  `json:"token"`
-	Action    *Action `json:"action"`
+	OpenID     string  `json:"open_id"`
+	UserID     string  `json:"user_id"`
+	TenantKey  string  `json:"tenant_key"`
+	OpenMsgID  string  `json:"open_message_id"`
+	OpenChatID string  `json:"open_chat_id"`
+	Token      string  `json:"token"`
+	Action     *Action `json:"action"`
+}
+
+// Operator returns the user who sent the message or clicked the card.
+func (m *Msg) Operator() string {
+	if m.Event.Sender.SenderIDs.UserID != "" {
+		return m.Event.Sender.SenderIDs.UserID
+	}
+	return m.UserID
+}
+
+// ChatID returns the chat the message was sent to or the card is in.
+func (m *Msg) ChatID() string {
+	if m.Event.Message.ChatID != "" {
+		return m.Event.Message.ChatID
+	}
+	return m.OpenChatID
 }
 
 type Action struct {
 	Value  *Value `json:"value"`
 	Tag    string `json:"tag"`
 	Option string `json:"option"`
+	// Name and FormValue come with the submit button of a form container,
+	// FormValue has the values of its fields by name
+	Name      string                 `json:"name,omitempty"`
+	FormValue map[string]interface{} `json:"form_value,omitempty"`
+}
+
+// FormString returns the value of a form field, empty when the field was not
+// filled or is not text.
+func (a *Action) FormString(name string) string {
+	s, _ := a.FormValue[name].(string)
+	return strings.TrimSpace(s)
 }
 
 type Value struct {
 	Key string `json:"key"`
+	// Case is the pk of the case a button acts on when the card is not the
+	// case card itself, e.g. an approval card
+	Case     string `json:"case,omitempty"`
+	Decision string `json:"decision,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// Card names a card that is not a case card, Op, Target and Page are the
+	// row action of such a card
+	Card   string `json:"card,omitempty"`
+	Op     string `json:"op,omitempty"`
+	Target string `json:"target,omitempty"`
+	Page   string `json:"page,omitempty"`
 }
@@ -1,5 +1,7 @@
 package model
 
+import "encoding/json"
+
 type FeiShuResponse struct {
 	Code int    `json:"code,omitempty"`
 	Data *Data  `json:"data,omitempty"`
@@ -18,6 +20,7 @@ type TokenResp struct {
 	Code    int    `json:"code,omitempty"`
 	Msg     string `json:"msg,omitempty"`
 	TAToken string `json:"tenant_access_token,omitempty"`
+	Expire  int    `json:"expire,omitempty"`
 }
 
 type Content struct {
@@ -41,6 +44,18 @@ type FeiShuMsg struct {
 	Card        Card     `json:"card"`
 }
 
+// Clone returns a deep copy, templates from the cached config must not be
+// modified in place.
+func (m *FeiShuMsg) Clone() *FeiShuMsg {
+	c := &FeiShuMsg{}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return c
+	}
+	json.Unmarshal(b, c)
+	return c
+}
+
 type I18nNames struct {
 	ZhCn string `json:"zh_cn,omitempty"`
 	EnUs string `json:"en_us,omitempty"`
@@ -5,4 +5,11 @@ import "msg-event/model"
 type MsgResponse struct {
 	Challenge string           `json:"challenge,omitempty"`
 	Elements  []model.Elements `json:"elements,omitempty"`
+	Card      *Card            `json:"card,omitempty"`
+}
+
+// Card replaces the clicked card in the response of a v2 card callback.
+type Card struct {
+	Type string      `json:"type"`
+	Data *model.Card `json:"data"`
 }
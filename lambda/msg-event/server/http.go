@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/services"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxBodyBytes    = 1 << 20
+	requestTimeout  = 60 * time.Second
+	shutdownTimeout = 30 * time.Second
+)
+
+// Handle runs a raw Lark callback through the verified pipeline and returns
+// the http status and body to reply with. It is shared by the Lambda proxy
+// integration and the standalone server.
+func Handle(ctx context.Context, header http.Header, body []byte) (int, []byte) {
+	r, err := services.ServeWebhook(ctx, header, body)
+	if errors.Is(err, services.ErrVerifyFailed) {
+		logrus.Warnf("reject request %v", err)
+		return http.StatusUnauthorized, nil
+	}
+	if err != nil {
+		logrus.Errorf("handle err %v", err)
+	}
+	if r == nil {
+		return http.StatusInternalServerError, nil
+	}
+	s, _ := json.Marshal(r)
+	return http.StatusOK, s
+}
+
+type httpServer struct {
+	ready    atomic.Bool
+	stopping atomic.Bool
+}
+
+// ListenAndServe serves the event subscription and card callback endpoints
+// on addr until ctx is cancelled, then drains in-flight requests.
+func ListenAndServe(ctx context.Context, addr string) error {
+	s := &httpServer{}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /messages", http.TimeoutHandler(http.HandlerFunc(s.webhook), requestTimeout, ""))
+	mux.Handle("POST /cards", http.TimeoutHandler(http.HandlerFunc(s.webhook), requestTimeout, ""))
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      requestTimeout + 5*time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logrus.Infof("http server listening on %s", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+	go func() {
+		if err := dao.SetupConfig(); err != nil {
+			logrus.Errorf("setup config failed %s, /readyz will report not ready", err)
+			return
+		}
+		s.ready.Store(config.Get() != nil)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logrus.Infof("shutting down http server")
+	s.stopping.Store(true)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *httpServer) webhook(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			logrus.Infof("panic is %v", string(debug.Stack()))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		logrus.Errorf("failed to read body %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	status, rsp := Handle(r.Context(), r.Header, body)
+	if rsp != nil {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(rsp)
+}
+
+func (s *httpServer) healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *httpServer) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.stopping.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if !s.ready.Load() {
+		// keep retrying so the pod becomes ready once DynamoDB is reachable
+		if err := dao.SetupConfig(); err != nil || config.Get() == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.ready.Store(true)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"msg-event/dao"
+	"msg-event/model/event"
+	"msg-event/model/response"
+	"msg-event/services"
+	"sync"
+	"time"
+
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkws "github.com/larksuite/oapi-sdk-go/v3/ws"
+	"github.com/sirupsen/logrus"
+)
+
+// longConnEvents are the Lark events taken over the long connection, card
+// actions come as callbacks.
+var longConnEvents = []string{
+	event.TypeMessageReceive,
+	event.TypeBotAdded,
+	event.TypeMessageRecalled,
+}
+
+var errStopping = errors.New("long connection is shutting down")
+
+type serveFunc func(ctx context.Context, e *event.Envelope) (*response.MsgResponse, error)
+
+// ServeLongConn receives events over the Lark long connection instead of a
+// public callback URL. The SDK client reconnects on its own until ctx is
+// cancelled, in-flight events are answered before it returns.
+func ServeLongConn(ctx context.Context, domain string) error {
+	if err := dao.SetupConfig(); err != nil {
+		return err
+	}
+	id, err := dao.GetAppID()
+	if err != nil {
+		return err
+	}
+	sec, err := dao.GetAPPSecret()
+	if err != nil {
+		return err
+	}
+	return runLongConn(ctx, domain, id, sec, services.Serve)
+}
+
+func runLongConn(ctx context.Context, domain, appID, appSecret string, serve serveFunc) error {
+	h := &longConnHandler{serve: serve}
+	cli := larkws.NewClient(appID, appSecret,
+		larkws.WithDomain(domain),
+		larkws.WithEventHandler(h.dispatcher()),
+		larkws.WithLogger(sdkLogger{}),
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		// events keep their context while the connection shuts down
+		errCh <- cli.Start(context.WithoutCancel(ctx))
+	}()
+	select {
+	case err := <-errCh:
+		// rejected by Lark, e.g. bad credentials, retrying will not help
+		return err
+	case <-ctx.Done():
+	}
+	h.shutdown()
+	return nil
+}
+
+// longConnHandler passes the events of the SDK dispatcher to the same
+// pipeline as the webhook. The long connection is authenticated by the app
+// secret, its events are neither signed nor encrypted.
+type longConnHandler struct {
+	serve    serveFunc
+	mu       sync.Mutex
+	stopping bool
+	inflight sync.WaitGroup
+}
+
+func (h *longConnHandler) dispatcher() *dispatcher.EventDispatcher {
+	d := dispatcher.NewEventDispatcher("", "")
+	for _, t := range longConnEvents {
+		d.OnCustomizedEvent(t, func(ctx context.Context, req *larkevent.EventReq) error {
+			_, err := h.handle(ctx, req.Body)
+			return err
+		})
+	}
+	d.OnP2CardActionTrigger(func(ctx context.Context, e *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+		if e.EventReq == nil {
+			return nil, fmt.Errorf("card action without body")
+		}
+		r, err := h.handle(ctx, e.EventReq.Body)
+		if err != nil {
+			return nil, err
+		}
+		resp := &callback.CardActionTriggerResponse{}
+		if r != nil && r.Card != nil {
+			resp.Card = &callback.Card{Type: r.Card.Type, Data: r.Card.Data}
+		}
+		return resp, nil
+	})
+	return d
+}
+
+// handle serves the event unless the connection is shutting down, a failed
+// event is answered with an error and delivered again by Lark.
+func (h *longConnHandler) handle(ctx context.Context, body []byte) (*response.MsgResponse, error) {
+	h.mu.Lock()
+	if h.stopping {
+		h.mu.Unlock()
+		return nil, errStopping
+	}
+	h.inflight.Add(1)
+	h.mu.Unlock()
+	defer h.inflight.Done()
+
+	e := &event.Envelope{}
+	if err := json.Unmarshal(body, e); err != nil {
+		logrus.Errorf("failed to unmarshal event %v", err)
+		return nil, err
+	}
+	r, err := h.serve(ctx, e)
+	if err != nil {
+		logrus.Errorf("handle err %v", err)
+	}
+	return r, nil
+}
+
+// shutdown stops taking new events and waits for the running ones.
+func (h *longConnHandler) shutdown() {
+	h.mu.Lock()
+	h.stopping = true
+	h.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(shutdownTimeout):
+		logrus.Warnf("in-flight events not finished before shutdown")
+	}
+}
+
+// sdkLogger sends the logs of the SDK client to logrus.
+type sdkLogger struct{}
+
+func (sdkLogger) Debug(_ context.Context, args ...interface{}) { logrus.Debug(args...) }
+func (sdkLogger) Info(_ context.Context, args ...interface{})  { logrus.Info(args...) }
+func (sdkLogger) Warn(_ context.Context, args ...interface{})  { logrus.Warn(args...) }
+func (sdkLogger) Error(_ context.Context, args ...interface{}) { logrus.Error(args...) }
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/model/response"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	larkws "github.com/larksuite/oapi-sdk-go/v3/ws"
+)
+
+// fakeLark serves the endpoint and the websocket of the long connection.
+type fakeLark struct {
+	srv   *httptest.Server
+	conns chan *websocket.Conn
+}
+
+func newFakeLark(t *testing.T) *fakeLark {
+	f := &fakeLark{conns: make(chan *websocket.Conn, 1)}
+	mux := http.NewServeMux()
+	mux.HandleFunc(larkws.GenEndpointUri, func(w http.ResponseWriter, r *http.Request) {
+		u := "ws" + strings.TrimPrefix(f.srv.URL, "http") + "/ws?device_id=d1&service_id=1"
+		json.NewEncoder(w).Encode(larkws.EndpointResp{
+			Code: larkws.OK,
+			Data: &larkws.Endpoint{Url: u, ClientConfig: &larkws.ClientConfig{PingInterval: 120}},
+		})
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		c, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed %v", err)
+			return
+		}
+		f.conns <- c
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeLark) accept(t *testing.T) *websocket.Conn {
+	select {
+	case c := <-f.conns:
+		t.Cleanup(func() { c.Close() })
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not connect")
+		return nil
+	}
+}
+
+// send splits the payload into one data frame per part.
+func send(t *testing.T, c *websocket.Conn, msgID string, parts ...string) {
+	for i, p := range parts {
+		hs := larkws.Headers{}
+		hs.Add(larkws.HeaderType, string(larkws.MessageTypeEvent))
+		hs.Add(larkws.HeaderMessageID, msgID)
+		hs.Add(larkws.HeaderSum, strconv.Itoa(len(parts)))
+		hs.Add(larkws.HeaderSeq, strconv.Itoa(i))
+		frame := larkws.Frame{Method: int32(larkws.FrameTypeData), Service: 1, Headers: hs, Payload: []byte(p)}
+		bs, err := frame.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = c.WriteMessage(websocket.BinaryMessage, bs); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// recv returns the answer of the client, pings are skipped.
+func recv(t *testing.T, c *websocket.Conn) (string, *larkws.Response) {
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, msg, err := c.ReadMessage()
+		if err != nil {
+			t.Fatalf("no answer %v", err)
+		}
+		frame := larkws.Frame{}
+		if err = frame.Unmarshal(msg); err != nil {
+			t.Fatal(err)
+		}
+		if larkws.FrameType(frame.Method) != larkws.FrameTypeData {
+			continue
+		}
+		resp := &larkws.Response{}
+		if err = json.Unmarshal(frame.Payload, resp); err != nil {
+			t.Fatal(err)
+		}
+		return larkws.Headers(frame.Headers).GetString(larkws.HeaderMessageID), resp
+	}
+}
+
+// recorder is the pipeline of the tests, card actions are answered with a
+// card titled with the key of the button.
+type recorder struct {
+	mu     sync.Mutex
+	served []string
+}
+
+func (r *recorder) serve(_ context.Context, e *event.Envelope) (*response.MsgResponse, error) {
+	r.mu.Lock()
+	r.served = append(r.served, e.EventType())
+	r.mu.Unlock()
+	if e.EventType() != event.TypeCardAction {
+		return &response.MsgResponse{}, nil
+	}
+	payload, err := e.CardAction()
+	if err != nil {
+		return nil, err
+	}
+	card := &model.Card{Header: &model.Header{Title: model.Text{Tag: "plain_text", Content: payload.Action.Value.Key}}}
+	return &response.MsgResponse{Card: &response.Card{Type: "raw", Data: card}}, nil
+}
+
+const (
+	messageEvent = `{"schema":"2.0","header":{"event_id":"e1","event_type":"im.message.receive_v1"},"event":{"message":{"message_id":"m1","message_type":"text"}}}`
+	cardEvent    = `{"schema":"2.0","header":{"event_id":"e2","event_type":"card.action.trigger"},"event":{"operator":{"open_id":"ou_1"},"action":{"value":{"key":"结案"}},"context":{"open_message_id":"om_1","open_chat_id":"oc_1"}}}`
+	unknownEvent = `{"schema":"2.0","header":{"event_id":"e3","event_type":"contact.user.created_v3"},"event":{}}`
+)
+
+func TestLongConnDispatch(t *testing.T) {
+	f := newFakeLark(t)
+	rec := &recorder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runLongConn(ctx, f.srv.URL, "cli_test", "secret", rec.serve)
+	c := f.accept(t)
+
+	tests := []struct {
+		name      string
+		parts     []string
+		wantCode  int
+		wantType  string
+		wantTitle string
+	}{
+		{name: "message", parts: []string{messageEvent}, wantCode: http.StatusOK, wantType: event.TypeMessageReceive},
+		{name: "fragmented message", parts: []string{messageEvent[:40], messageEvent[40:90], messageEvent[90:]},
+			wantCode: http.StatusOK, wantType: event.TypeMessageReceive},
+		{name: "card action", parts: []string{cardEvent}, wantCode: http.StatusOK, wantType: event.TypeCardAction, wantTitle: "结案"},
+		{name: "unsubscribed event", parts: []string{unknownEvent}, wantCode: http.StatusInternalServerError},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.mu.Lock()
+			rec.served = nil
+			rec.mu.Unlock()
+			msgID := "msg_" + strconv.Itoa(i)
+			send(t, c, msgID, tt.parts...)
+
+			gotID, resp := recv(t, c)
+			if gotID != msgID {
+				t.Errorf("answered %s, want %s", gotID, msgID)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("code %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			rec.mu.Lock()
+			served := rec.served
+			rec.mu.Unlock()
+			if tt.wantType == "" {
+				if len(served) != 0 {
+					t.Errorf("served %v, want nothing", served)
+				}
+				return
+			}
+			if len(served) != 1 || served[0] != tt.wantType {
+				t.Errorf("served %v, want [%s]", served, tt.wantType)
+			}
+			if tt.wantTitle == "" {
+				return
+			}
+			card := struct {
+				Card struct {
+					Type string      `json:"type"`
+					Data *model.Card `json:"data"`
+				} `json:"card"`
+			}{}
+			if err := json.Unmarshal(resp.Data, &card); err != nil {
+				t.Fatalf("bad card response %s, %v", resp.Data, err)
+			}
+			if card.Card.Type != "raw" || card.Card.Data == nil || card.Card.Data.Header.Title.Content != tt.wantTitle {
+				t.Errorf("card response %s, want a raw card titled %s", resp.Data, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestLongConnShutdownDrainsEvents(t *testing.T) {
+	f := newFakeLark(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	serve := func(ctx context.Context, e *event.Envelope) (*response.MsgResponse, error) {
+		close(started)
+		<-release
+		if ctx.Err() != nil {
+			t.Errorf("event context cancelled by the shutdown")
+		}
+		return &response.MsgResponse{}, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runLongConn(ctx, f.srv.URL, "cli_test", "secret", serve) }()
+	c := f.accept(t)
+
+	send(t, c, "slow", messageEvent)
+	<-started
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("returned before the in-flight event was answered")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// events arriving while shutting down are refused and delivered again
+	send(t, c, "late", messageEvent)
+	if id, resp := recv(t, c); id != "late" || resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("late event answered %s with %d, want late with 500", id, resp.StatusCode)
+	}
+
+	close(release)
+	if id, resp := recv(t, c); id != "slow" || resp.StatusCode != http.StatusOK {
+		t.Errorf("slow event answered %s with %d, want slow with 200", id, resp.StatusCode)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("shutdown returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not return after the in-flight event")
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"msg-event/config"
 	"msg-event/dao"
 	"msg-event/model/event"
 )
@@ -8,4 +9,22 @@ import (
 type Server interface {
 	Handle(e *event.Msg, str string) (c *dao.Case, err error)
 	ShouldHandle(e *event.Msg) bool
+	// RequiredRole is checked by the dispatcher before Handle is called.
+	RequiredRole() config.Role
+	// Describe names the command the server is registered under and
+	// documents it on the help card.
+	Describe() Command
+}
+
+// Command describes a command, e.g. Name "开工单" with the Syntax key of
+// "<工单题目>". Syntax and Description are keys of the message catalog, the
+// help card shows them in the locale of the caller.
+type Command struct {
+	Name    string
+	Aliases []string
+	// Syntax shows the arguments, empty for a command without arguments
+	Syntax      string
+	Description string
+	// Hidden keeps a command that only card buttons send off the help card
+	Hidden bool
 }
@@ -2,130 +2,115 @@ package services
 
 import (
 	"context"
-	"errors"
-	"msg-event/config"
+	"encoding/json"
 	"msg-event/dao"
 	"msg-event/model/event"
 	"msg-event/model/response"
 	"msg-event/services/api"
+	"msg-event/services/handlers"
 	"msg-event/services/processors"
-	"strings"
+	"net/http"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
+// processorManager picks the processor of im.message.receive_v1 by message type
 var processorManager map[string]api.Processor
 
 func InitProcessors() {
 	processorManager = map[string]api.Processor{
-		"fresh_comment": processors.GetRefreshCommentProcessor(),
-		"card":          processors.GetCardProcessor(),
-		"text":          processors.GetTextProcessor(),
-		"image":         processors.GetImageProcessor(),
-		"file":          processors.GetAttaProcessor(),
+		"text":  processors.GetTextProcessor(),
+		"image": processors.GetImageProcessor(),
+		"file":  processors.GetAttaProcessor(),
 	}
 }
 
-func Serve(_ context.Context, e *event.Msg) (event *response.MsgResponse, err error) {
+// Serve handles an event that is already trusted, e.g. the refresh event from EventBridge.
+func Serve(ctx context.Context, e *event.Envelope) (event *response.MsgResponse, err error) {
 	logrus.Infof("====================================================")
-	err = dao.SetupConfig()
-	processors.InitServices()
-	InitProcessors()
 	resp := &response.MsgResponse{
 		Challenge: e.Challenge,
 	}
-	if err != nil {
+	if err = setup(); err != nil {
 		logrus.Errorf("setup config failed %s", err)
 		return resp, err
 	}
+	return serve(ctx, e)
+}
 
+// ServeWebhook verifies and decrypts a raw callback from Lark before handling it.
+func ServeWebhook(ctx context.Context, header http.Header, body []byte) (event *response.MsgResponse, err error) {
+	logrus.Infof("====================================================")
+	if err = setup(); err != nil {
+		logrus.Errorf("setup config failed %s", err)
+		return nil, err
+	}
+	e, err := VerifyRequest(header, body)
+	if err != nil {
+		return nil, err
+	}
+	s, _ := json.Marshal(e)
+	logrus.Infof("verified event is %s", string(s))
+	return serve(ctx, e)
+}
 
-	if e.Action != nil && e.Event.Message.MsgType == "" {
-		e.Event.Message.MsgType = "card"
-    
+// initOnce builds the registries, they are read by concurrent requests in
+// the http and long connection modes and never change afterwards.
+var initOnce sync.Once
+
+// setup registers the handlers once and refreshes the config, which is
+// swapped as a whole when its cache expires.
+func setup() error {
+	initOnce.Do(func() {
+		processors.InitServices()
+		InitProcessors()
+		InitRouter()
+	})
+	return dao.SetupConfig()
+}
+
+func serve(ctx context.Context, e *event.Envelope) (event *response.MsgResponse, err error) {
+	resp := &response.MsgResponse{
+		Challenge: e.Challenge,
 	}
 
-	if e.Event.Message.MsgType != "" {
-		if !Processable(e) {
-			logrus.Infof("Duplicate message with same eventID")
-			return resp, nil
-		}
-		if v, ok := processorManager[e.Event.Message.MsgType]; ok {
-			logrus.Infof("event type %s. ", e.Event.Message.MsgType)
-
-			err = v.Process(e)
-			if err != nil {
-				logrus.Errorf("failed to process %v", err)
-				return resp, err
-			}
-		} else {
-			logrus.Errorf("unknown type %s", e.Event.Message.MsgType)
-			return resp, err
-		}
+	eventType := e.EventType()
+	r, ok := eventRouter[eventType]
+	if !ok {
+		logrus.Warnf("ignore unknown event type %q, event id %s", eventType, e.Header.EventID)
+		return resp, nil
 	}
+	logrus.Infof("event type %s. ", eventType)
 
+	if err = r(ctx, e, resp); err != nil {
+		logrus.Errorf("failed to process %v", err)
+		return resp, err
+	}
+	return resp, nil
+}
+
+// renderCase creates the case once the draft is complete and returns it so
+// the route can reply with the latest card.
+func renderCase(e *event.Msg) (*dao.Case, error) {
 	caze, err := dao.GetCaseByEvent(e)
 	if err != nil {
 		logrus.Errorf("failed to get case, %v", err)
-		return resp, err
+		return nil, err
 	}
 	if caze == nil {
-		logrus.Infof("Return challenge for url_verification")
-		return resp, nil
+		return nil, nil
 	}
 
-	if caze != nil && caze.Status == dao.STATUS_NEW {
+	if caze.Status == dao.STATUS_NEW || caze.Status == dao.STATUS_PENDING_APPROVAL {
 
 		// if user in list, create case and channel, else send no permission
 
-		err = createChatOrNewCase(caze)
+		err = handlers.CreateChatOrNewCase(caze)
 		if err != nil {
 			logrus.Errorf("process chat or create case failed case %+v, \n %v", caze, err)
 		}
 
 	}
-	resp.Elements = caze.CardMsg.Card.Elements
-	return resp, nil
-}
-
-func createChatOrNewCase(caze *dao.Case) error {
-	caze.Print()
-	_, sevOk := config.SevMap[caze.SevCode]
-	_, serviceOk := config.ServiceMap[caze.ServiceCode]
-	_, accountOK := config.Conf.Accounts[caze.AccountKey]
-
-	if strings.Trim(caze.Title, " ") != "" &&
-		strings.Trim(caze.Content, " ") != "" &&
-		strings.Trim(caze.SevCode, " ") != "" &&
-		sevOk &&
-		strings.Trim(caze.ServiceCode, " ") != "" &&
-		serviceOk &&
-		accountOK &&
-		caze.Status == dao.STATUS_NEW {
-
-		caze.Status = dao.STATUS_OPEN
-		caze, err := dao.CreateCaseAndChannel(caze)
-		if err != nil {
-			logrus.Errorf("failed to create case info %s", err)
-			return err
-		}
-		//clean up fromchannel
-		caze.ChannelID = caze.FromChannelID
-		caze.UserID = ""
-		caze.Title = ""
-		caze.Content = ""
-		caze.Type = dao.TYPE_OPEN_CASE
-		caze.SevCode = ""
-		caze.ServiceCode = ""
-		caze.CardMsg.ChatId = caze.ChannelID
-		caze.CardMsg.UserId = caze.UserID
-		_, err = dao.UpsertCase(caze)
-		if err != nil {
-			logrus.Errorf("failed to cleanup root case info %s", err)
-			return err
-		}
-		return nil
-	}
-	s := dao.FormatMsg(caze)
-	return errors.New(s)
+	return caze, nil
 }
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
+	"sort"
 	"strings"
 	"time"
 
@@ -25,21 +30,82 @@ func (s *accountServ) Handle(e *event.Msg, str string) (c *dao.Case, err error)
 		return nil, err
 	}
 	account := strings.Trim(str, " ")
-	_, ok := config.Conf.Accounts[account]
-	if !ok {
-		c.AccountKey = "0"
-	} else {
-		c.AccountKey = account
+	if err = dao.CheckAccountAccess(e.Operator(), account); err != nil {
+		// keep the previous selection, the card is rendered from the case
+		logrus.Warnf("user %s can not use account %s, %v", e.Operator(), account, err)
+		dao.SendMsgToChannel(c.ChannelID, i18n.T(locale(e), "permission.denied"))
+		return c, nil
 	}
+	c.AccountKey = account
 	c.UpdateTime = time.Now().String()
-	for i, element := range c.CardMsg.Card.Elements {
-		if element.Extra.Value.Key == e.Action.Value.Key {
-			c.CardMsg.Card.Elements[i].Extra.InitialOption = e.Action.Option
-		}
+	if i := cardElement(&c.CardMsg.Card, openCaseAccountKey); i >= 0 {
+		c.CardMsg.Card.Elements[i].Extra.InitialOption = account
+	}
+	catalog, err := caseCatalog(c)
+	if err != nil {
+		return nil, err
+	}
+	if err = refreshServices(c, catalog); err != nil {
+		return nil, err
 	}
-	return dao.UpsertCase(c)
+	levels, err := caseSeverities(c)
+	if err != nil {
+		return nil, err
+	}
+	refreshSeverities(c, levels)
+	if e.Action != nil {
+		return dao.UpsertCase(c)
+	}
+	return replyCard(c)
 }
 
 func (s *accountServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *accountServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *accountServ) Describe() api.Command {
+	return api.Command{Name: openCaseAccountKey, Aliases: []string{"ACCOUNT"}, Syntax: "cmd.account.syntax", Description: "cmd.account.desc"}
+}
+
+// accountOptions lists the given accounts for the account selector, the
+// option value is the account key.
+func accountOptions(keys []string) []model.Options {
+	conf := config.Get()
+	opts := make([]model.Options, 0, len(keys))
+	for _, key := range keys {
+		a := conf.Accounts[key]
+		if a == nil {
+			continue
+		}
+		label := dao.GetAccountName(key, a)
+		if id := dao.GetAccountIdFromRoleARN(a.RoleARN); a.RoleARN != "" && id != label {
+			label = fmt.Sprintf("%s (%s)", label, id)
+		}
+		opts = append(opts, model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: label},
+			Value: key,
+		})
+	}
+	sort.Slice(opts, func(i, j int) bool {
+		return opts[i].Text.Content < opts[j].Text.Content
+	})
+	return opts
+}
+
+// accountErr explains an account without support plan, other errors are
+// returned as is.
+func accountErr(err error) error {
+	var ae *dao.AccountError
+	if !errors.As(err, &ae) || !errors.Is(err, dao.ErrNoSupportPlan) {
+		return err
+	}
+	name := ae.AccountKey
+	if a, ok := config.Get().Accounts[ae.AccountKey]; ok && a != nil {
+		name = dao.GetAccountName(ae.AccountKey, a)
+	}
+	return i18n.Errorf("account.no_support_plan", name)
+}
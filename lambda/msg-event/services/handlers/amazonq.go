@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"msg-event/config"
 	"msg-event/dao"
 	"msg-event/model/event"
 	"msg-event/services/api"
@@ -28,3 +29,11 @@ func (s *qServ) Handle(e *event.Msg, content string) (c *dao.Case, err error) {
 func (s *qServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *qServ) RequiredRole() config.Role {
+	return config.RoleViewer
+}
+
+func (s *qServ) Describe() api.Command {
+	return api.Command{Name: "Q", Syntax: "cmd.q.syntax", Description: "cmd.q.desc"}
+}
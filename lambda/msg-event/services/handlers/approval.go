@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ApprovalKey     = "审批"
+	decisionApprove = "approve"
+	decisionReject  = "reject"
+	approvalExcerpt = 500
+)
+
+var severityRank = map[string]int{
+	"low":      1,
+	"normal":   2,
+	"high":     3,
+	"urgent":   4,
+	"critical": 5,
+}
+
+// needsApproval reports whether the draft is at or above the approval
+// severity of its account and not yet approved in its current version.
+func needsApproval(c *dao.Case) bool {
+	a, ok := config.Get().Accounts[c.AccountKey]
+	if !ok || a == nil || a.ApprovalSeverity == "" {
+		return false
+	}
+	if c.ApprovalStatus == dao.APPROVAL_APPROVED && c.ApprovalVersion == c.UpdateTime {
+		return false
+	}
+	return severityRank[c.SevCode] >= severityRank[a.ApprovalSeverity]
+}
+
+func getApprovers(c *dao.Case) []string {
+	var approvers []string
+	if a, ok := config.Get().Accounts[c.AccountKey]; ok && a != nil && len(a.Approvers) > 0 {
+		approvers = a.Approvers
+	} else {
+		approvers = dao.GetUsersWithRole(config.RoleApprover)
+	}
+	// nobody approves their own case
+	others := make([]string, 0, len(approvers))
+	for _, id := range approvers {
+		if id != c.UserID {
+			others = append(others, id)
+		}
+	}
+	return others
+}
+
+// requestApproval parks the draft in PENDING_APPROVAL and sends the approval
+// card to the approvers, once per version of the draft.
+func requestApproval(c *dao.Case) error {
+	if c.Status == dao.STATUS_PENDING_APPROVAL && c.ApprovalStatus == dao.APPROVAL_PENDING &&
+		c.ApprovalVersion == c.UpdateTime {
+		return nil
+	}
+	approvers := getApprovers(c)
+	if len(approvers) == 0 {
+		return i18n.Errorf("approval.no_approver")
+	}
+	expireApprovalCards(c, "approval.expired")
+
+	c.Status = dao.STATUS_PENDING_APPROVAL
+	c.ApprovalStatus = dao.APPROVAL_PENDING
+	c.ApprovalVersion = c.UpdateTime
+	c.ApprovalMsgIDs = nil
+	for _, id := range approvers {
+		rsp, err := dao.SendCardToUser(id, approvalCard(dao.GetLocale("", id), c))
+		if err != nil || rsp.Data == nil {
+			logrus.Errorf("failed to send approval card to %s, %v", id, err)
+			continue
+		}
+		c.ApprovalMsgIDs = append(c.ApprovalMsgIDs, *rsp.Data.MessageId)
+	}
+	if len(c.ApprovalMsgIDs) == 0 {
+		return i18n.Errorf("approval.send_failed")
+	}
+	if _, err := dao.UpsertCase(c); err != nil {
+		return err
+	}
+	_, err := dao.SendMsgToChannel(c.ChannelID, i18n.T(dao.CaseLocale(c), "approval.requested", c.SevCode))
+	return err
+}
+
+// approvalCard is the approval card in the locale of the approver.
+func approvalCard(l i18n.Locale, c *dao.Case) *model.Card {
+	account := c.AccountKey
+	if a, ok := config.Get().Accounts[c.AccountKey]; ok && a != nil {
+		account = dao.GetAccountName(c.AccountKey, a)
+	}
+	content := []rune(c.Content)
+	if len(content) > approvalExcerpt {
+		content = append(content[:approvalExcerpt], []rune("...")...)
+	}
+	value := func(decision string) map[string]string {
+		return map[string]string{
+			"key":      ApprovalKey,
+			"case":     c.ChannelID,
+			"decision": decision,
+			"version":  c.ApprovalVersion,
+		}
+	}
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "approval.title")},
+			Template: "orange",
+		},
+		Elements: []model.Elements{
+			{
+				Tag: "markdown",
+				Content: i18n.T(l, "approval.card",
+					c.UserID, c.Title, account, issueTypeName(l, c), c.ServiceCode, c.CategoryCode, c.SevCode, string(content)),
+			},
+			{
+				Tag: "action",
+				Actions: []model.Button{
+					{Tag: "button", Text: model.Text{Tag: "plain_text", Content: i18n.T(l, "approval.approve")}, Type: "primary", Value: value(decisionApprove)},
+					{Tag: "button", Text: model.Text{Tag: "plain_text", Content: i18n.T(l, "approval.reject")}, Type: "danger", Value: value(decisionReject)},
+				},
+			},
+		},
+	}
+}
+
+// resultCard replaces the approval cards, the cards of all approvers show
+// the same text in the locale of the tenant.
+func resultCard(key string, args ...interface{}) *model.Card {
+	l := dao.DefaultLocale()
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "approval.title")},
+			Template: "grey",
+		},
+		Elements: []model.Elements{{Tag: "markdown", Content: i18n.T(l, key, args...)}},
+	}
+}
+
+func expireApprovalCards(c *dao.Case, key string, args ...interface{}) {
+	card := resultCard(key, args...)
+	for _, id := range c.ApprovalMsgIDs {
+		if err := dao.UpdateCardMsg(id, card); err != nil {
+			logrus.Warnf("failed to update approval card %s, %v", id, err)
+		}
+	}
+}
+
+type approvalServ struct {
+}
+
+func GetApprovalServ() api.Server {
+	return &approvalServ{}
+}
+
+func (s *approvalServ) ShouldHandle(e *event.Msg) bool {
+	return e.Action != nil && e.Action.Value != nil && e.Action.Value.Case != ""
+}
+
+// RequiredRole lets submitters in, the approvers of an account need no
+// global role; Handle checks that the operator approves this case.
+func (s *approvalServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *approvalServ) Describe() api.Command {
+	return api.Command{Name: ApprovalKey, Hidden: true, Description: "cmd.approval.desc"}
+}
+
+// Handle records the decision of an approver on the draft and opens the case
+// when it is approved. The approval cards of all approvers show the result.
+func (s *approvalServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	if !s.ShouldHandle(e) {
+		return nil, errors.New("invalid approval action")
+	}
+	v := e.Action.Value
+	c, err = dao.GetCase(v.Case)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != dao.STATUS_PENDING_APPROVAL || c.ApprovalVersion != v.Version {
+		dao.UpdateCardMsg(e.OpenMsgID, resultCard("approval.expired"))
+		return c, nil
+	}
+	operator := e.Operator()
+	if !contains(getApprovers(c), operator) {
+		logrus.Warnf("user %s is not an approver of case %s", operator, c.ChannelID)
+		return nil, dao.SendNoPermissionCard(e.ChatID(), operator, config.RoleApprover)
+	}
+
+	c.Approver = operator
+	c.ApprovalTime = time.Now().String()
+	l := dao.CaseLocale(c)
+	var notice, result string
+	switch v.Decision {
+	case decisionApprove:
+		c.ApprovalStatus = dao.APPROVAL_APPROVED
+		c.Status = dao.STATUS_NEW
+		notice, result = i18n.T(l, "approval.notify_approved", operator), "approval.result_approved"
+	case decisionReject:
+		c.ApprovalStatus = dao.APPROVAL_REJECTED
+		c.Status = dao.STATUS_REJECTED
+		notice, result = i18n.T(l, "approval.notify_rejected", operator), "approval.result_rejected"
+	default:
+		return nil, errors.New("unknown approval decision " + v.Decision)
+	}
+	if _, err = dao.UpsertCase(c); err != nil {
+		return nil, err
+	}
+	expireApprovalCards(c, result, c.Title, operator)
+	dao.SendMsgToChannel(c.ChannelID, notice)
+
+	if c.ApprovalStatus == dao.APPROVAL_APPROVED {
+		if err = CreateChatOrNewCase(c); err != nil {
+			logrus.Errorf("failed to create approved case %v", err)
+			dao.SendErrCardMsg(c.ChannelID, c.UserID, err)
+		}
+	}
+	return c, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
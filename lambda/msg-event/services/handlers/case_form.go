@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// caseFormKey is the command that sends the form and the key of its
+	// submit button
+	caseFormKey      = "表单开工单"
+	caseFormCardName = "case_form"
+)
+
+type caseFormServ struct {
+}
+
+func GetCaseFormServ() api.Server {
+	return &caseFormServ{}
+}
+
+// Handle sends the case form. The submit button comes back here with the
+// form values and the case is created from them at once.
+func (s *caseFormServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	if e.Action != nil && e.Action.Value != nil && e.Action.Value.Card == caseFormCardName {
+		if err = submitCaseForm(e); err != nil {
+			// the form stays for the user to correct
+			logrus.Warnf("case form of user %s not submitted, %v", e.Operator(), err)
+			dao.SendErrCardMsg(e.ChatID(), e.Operator(), err)
+		}
+		return nil, nil
+	}
+
+	userID := e.Operator()
+	accounts, err := dao.AllowedAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, errNoAccount
+	}
+	draft := &dao.Case{UserID: userID, IssueType: dao.ISSUE_TECHNICAL}
+	catalog, levels, err := formOptions(draft, accounts)
+	if err != nil {
+		return nil, err
+	}
+	_, err = dao.SendCardMsg(&model.FeiShuMsg{
+		ChatId: e.ChatID(),
+		Card:   *caseFormCard(locale(e), accounts, issueServices(draft, catalog), levels),
+	}, nil)
+	return nil, err
+}
+
+func (s *caseFormServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *caseFormServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *caseFormServ) Describe() api.Command {
+	return api.Command{Name: caseFormKey, Aliases: []string{"FORM"}, Description: "cmd.case_form.desc"}
+}
+
+// formOptions loads the services and severities of the first account that
+// can be used, the form can not update them for the chosen account, which is
+// checked on submit.
+func formOptions(draft *dao.Case, accounts []string) (*dao.ServiceCatalog, []dao.SeverityLevel, error) {
+	var err error
+	for _, key := range accounts {
+		draft.AccountKey = key
+		var catalog *dao.ServiceCatalog
+		var levels []dao.SeverityLevel
+		if catalog, err = caseCatalog(draft); err == nil {
+			if levels, err = caseSeverities(draft); err == nil {
+				return catalog, levels, nil
+			}
+		}
+		logrus.Warnf("account %s not listed on the case form, %v", key, err)
+	}
+	return nil, nil, err
+}
+
+// submitCaseForm checks the form values, fills the draft card of the chat
+// like a case template and creates the case, the case group gets the same
+// status card as a case opened by command.
+func submitCaseForm(e *event.Msg) error {
+	a := e.Action
+	chatID, userID := e.ChatID(), e.Operator()
+	l := locale(e)
+	fields := map[string]string{
+		"问题":   a.FormString("title"),
+		"内容":   a.FormString("content"),
+		"账户":   a.FormString("account"),
+		"服务":   a.FormString("service"),
+		"类别":   strings.TrimSpace(a.FormString("category")),
+		"响应速度": a.FormString("severity"),
+	}
+	for _, f := range caseTemplateRequired {
+		if fields[f] == "" {
+			return i18n.Errorf("form.required")
+		}
+	}
+	if err := dao.CheckAccountAccess(userID, fields["账户"]); err != nil {
+		if errors.Is(err, dao.ErrAccountDenied) {
+			return i18n.Errorf("permission.denied")
+		}
+		return err
+	}
+	accounts, err := dao.AllowedAccounts(userID)
+	if err != nil {
+		return err
+	}
+	if started := a.FormString("started"); started != "" {
+		fields["内容"] = i18n.T(l, "form.started_at", started, fields["内容"])
+	}
+
+	c := &dao.Case{Title: fields["问题"], Locale: string(l), UserID: userID, IssueType: dao.ISSUE_TECHNICAL}
+	if err = newCaseCard(c, chatID, accounts); err != nil {
+		return err
+	}
+	if err = applyCaseTemplate(c, fields); err != nil {
+		return err
+	}
+	if c.CategoryCode == "" {
+		return i18n.Errorf("form.category_required", categoryNames(c))
+	}
+	card := &c.CardMsg.Card
+	if i := cardElement(card, openCaseTitleKey); i >= 0 {
+		card.Elements[i].Content += c.Title
+	}
+
+	if c, err = dao.OpenCase(c, chatID, userID, e.OpenMsgID, c.CardMsg); err != nil {
+		return err
+	}
+	if err = CreateChatOrNewCase(c); err != nil {
+		return err
+	}
+	result := i18n.T(l, "form.created", c.Title)
+	if c.Status == dao.STATUS_PENDING_APPROVAL {
+		result = i18n.T(l, "form.pending_approval", c.Title)
+	}
+	return dao.UpdateCardMsg(e.OpenMsgID, caseFormResultCard(l, result))
+}
+
+// categoryNames lists the categories of the chosen service for the user to
+// pick one.
+func categoryNames(c *dao.Case) string {
+	l := dao.CaseLocale(c)
+	i := cardElement(&c.CardMsg.Card, categoryKey)
+	if i < 0 {
+		return ""
+	}
+	var names []string
+	for _, o := range c.CardMsg.Card.Elements[i].Extra.Options {
+		names = append(names, o.Text.Content)
+	}
+	return strings.Join(names, i18n.T(l, "list.separator"))
+}
+
+func caseFormCard(l i18n.Locale, accounts []string, services []dao.CatalogService, levels []dao.SeverityLevel) *model.Card {
+	label := func(s string) *model.Text {
+		return &model.Text{Tag: "plain_text", Content: s}
+	}
+	placeholder := func(s string) *model.Placeholder {
+		return &model.Placeholder{Tag: "plain_text", Content: s}
+	}
+	serviceOpts := make([]model.Options, 0, min(len(services), maxServiceOptions))
+	for i := 0; i < len(services) && i < maxServiceOptions; i++ {
+		serviceOpts = append(serviceOpts, model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: services[i].Name},
+			Value: services[i].Code,
+		})
+	}
+	account := ""
+	if len(accounts) == 1 {
+		account = accounts[0]
+	}
+
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "form.title")},
+			Template: "blue",
+		},
+		Elements: []model.Elements{
+			{
+				Tag:  "form",
+				Name: caseFormCardName,
+				Fields: []model.FormField{
+					{Tag: "input", Name: "title", Label: label(i18n.T(l, "field.title")), Placeholder: placeholder(i18n.T(l, "form.title.placeholder")), Required: true},
+					{Tag: "input", Name: "content", Label: label(i18n.T(l, "field.content")), InputType: "multiline_text", Rows: 6, Required: true,
+						Placeholder: placeholder(i18n.T(l, "form.content.placeholder"))},
+					{Tag: "select_static", Name: "account", Placeholder: placeholder(i18n.T(l, "form.account.placeholder")), Required: true,
+						Options: accountOptions(accounts), InitialOption: account},
+					{Tag: "select_static", Name: "service", Placeholder: placeholder(i18n.T(l, "form.service.placeholder")), Required: true,
+						Options: serviceOpts},
+					{Tag: "input", Name: "category", Placeholder: placeholder(i18n.T(l, "form.category.placeholder"))},
+					{Tag: "select_static", Name: "severity", Placeholder: placeholder(i18n.T(l, "form.severity.placeholder")), Required: true,
+						Options: severityOptions(l, levels)},
+					{Tag: "picker_datetime", Name: "started", Placeholder: placeholder(i18n.T(l, "form.started.placeholder"))},
+					{Tag: "button", Name: "submit", Text: label(i18n.T(l, "form.submit")), Type: "primary", ActionType: "form_submit",
+						Value: map[string]string{"key": caseFormKey, "card": caseFormCardName}},
+				},
+			},
+			{
+				Tag:     "markdown",
+				Content: i18n.T(l, "form.note"),
+			},
+		},
+	}
+}
+
+func caseFormResultCard(l i18n.Locale, text string) *model.Card {
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "form.title")},
+			Template: "grey",
+		},
+		Elements: []model.Elements{{Tag: "markdown", Content: text}},
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"time"
+)
+
+// statusNoticeTemplates are the header colors of the status notices, the
+// statuses not listed are blue.
+var statusNoticeTemplates = map[string]string{
+	dao.AWS_STATUS_PENDING_CUSTOMER_ACTION: "red",
+	dao.AWS_STATUS_RESOLVED:                "green",
+}
+
+// sendStatusNotice sends the notice card, tests replace it.
+var sendStatusNotice = func(msg *model.FeiShuMsg) error {
+	_, err := dao.SendCardMsg(msg, nil)
+	return err
+}
+
+// NotifyStatusChange posts the latest change of the AWS Support status to the
+// case group, the submitter is mentioned when AWS waits for them. The first
+// status seen of a case is only recorded.
+func NotifyStatusChange(c *dao.Case) error {
+	change, ok := c.LastStatusChange()
+	if !ok || change.From == "" {
+		return nil
+	}
+	return sendStatusNotice(&model.FeiShuMsg{ChatId: c.ChannelID, Card: *statusNoticeCard(c, change)})
+}
+
+func statusNoticeCard(c *dao.Case, change dao.StatusChange) *model.Card {
+	l := dao.CaseLocale(c)
+	content := i18n.T(l, "case_status.notice", c.DisplayCaseID, statusName(l, change.From), statusName(l, change.To),
+		change.Time.Format(time.DateTime+" MST"))
+	if change.To == dao.AWS_STATUS_PENDING_CUSTOMER_ACTION {
+		content += "\n" + i18n.T(l, "case_status.pending_customer", c.UserID)
+	}
+	template, ok := statusNoticeTemplates[change.To]
+	if !ok {
+		template = "blue"
+	}
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "case_status.title", statusName(l, change.To))},
+			Template: template,
+		},
+		Elements: []model.Elements{{Tag: "markdown", Content: content}},
+	}
+}
+
+// statusName is the name of the AWS Support status in the locale, unknown
+// statuses keep their code.
+func statusName(l i18n.Locale, status string) string {
+	if status == "" {
+		return i18n.T(l, "aws_status.unknown")
+	}
+	key := "aws_status." + status
+	if name := i18n.T(l, key); name != key {
+		return name
+	}
+	return status
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"strings"
+	"testing"
+)
+
+func TestNotifyStatusChange(t *testing.T) {
+	var sent []*model.FeiShuMsg
+	prev := sendStatusNotice
+	sendStatusNotice = func(msg *model.FeiShuMsg) error {
+		sent = append(sent, msg)
+		return nil
+	}
+	t.Cleanup(func() { sendStatusNotice = prev })
+
+	c := &dao.Case{ChannelID: "oc_1", Locale: "en-US", Status: dao.STATUS_OPEN}
+	steps := []struct {
+		status     string
+		wantStatus string
+		wantNotice bool
+	}{
+		{status: dao.AWS_STATUS_UNASSIGNED, wantStatus: dao.STATUS_OPEN},
+		{status: dao.AWS_STATUS_WORK_IN_PROGRESS, wantStatus: dao.STATUS_OPEN, wantNotice: true},
+		{status: dao.AWS_STATUS_RESOLVED, wantStatus: dao.STATUS_CLOSE, wantNotice: true},
+		{status: dao.AWS_STATUS_RESOLVED, wantStatus: dao.STATUS_CLOSE},
+		{status: dao.AWS_STATUS_REOPENED, wantStatus: dao.STATUS_OPEN, wantNotice: true},
+	}
+	for _, st := range steps {
+		sent = nil
+		if changed := c.SetAWSStatus(st.status); changed {
+			if err := NotifyStatusChange(c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if c.Status != st.wantStatus {
+			t.Errorf("%s: case %s, want %s", st.status, c.Status, st.wantStatus)
+		}
+		if c.Status == dao.STATUS_CLOSE && c.ClosedAt.IsZero() {
+			t.Errorf("%s: closed case without the closing time", st.status)
+		}
+		if c.Status == dao.STATUS_OPEN && !c.ClosedAt.IsZero() {
+			t.Errorf("%s: open case keeps the closing time", st.status)
+		}
+		if (len(sent) == 1) != st.wantNotice {
+			t.Fatalf("%s: %d notices, want notice %v", st.status, len(sent), st.wantNotice)
+		}
+		if !st.wantNotice {
+			continue
+		}
+		title := sent[0].Card.Header.Title.Content
+		if name := statusName(i18n.EnUS, st.status); sent[0].ChatId != "oc_1" || !strings.Contains(title, name) {
+			t.Errorf("%s: notice %q to %s, want %q to oc_1", st.status, title, sent[0].ChatId, name)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// confirmKey is the confirm button of a draft filled from a template.
+const confirmKey = "确认工单"
+
+// caseTemplateFields are the keys of the "key: value" lines of a template
+// and the catalog keys of their names, 类别 may be left out when the service
+// has a single category.
+var caseTemplateFields = map[string]string{
+	"问题":   "field.title",
+	"内容":   "field.content",
+	"账户":   "field.account",
+	"服务":   "field.service",
+	"类别":   "field.category",
+	"响应速度": "field.severity",
+}
+
+// caseTemplateAliases are the English keys of the template, case is ignored.
+var caseTemplateAliases = map[string]string{
+	"title":       "问题",
+	"subject":     "问题",
+	"content":     "内容",
+	"description": "内容",
+	"account":     "账户",
+	"service":     "服务",
+	"category":    "类别",
+	"severity":    "响应速度",
+}
+
+var caseTemplateRequired = []string{"问题", "内容", "账户", "服务", "响应速度"}
+
+type confirmServ struct {
+}
+
+func GetConfirmServ() api.Server {
+	return &confirmServ{}
+}
+
+// Handle confirms a draft filled from a template, the case is created once
+// the draft is complete.
+func (s *confirmServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCaseByEvent(e)
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	if !c.Confirming {
+		return c, nil
+	}
+	if missing := missingFields(c); len(missing) > 0 {
+		l := dao.CaseLocale(c)
+		return nil, i18n.Errorf("template.incomplete", strings.Join(fieldNames(l, missing), i18n.T(l, "list.separator")))
+	}
+	c.Confirming = false
+	removeCardElement(&c.CardMsg.Card, confirmKey)
+	c.UpdateTime = time.Now().String()
+	return dao.UpsertCase(c)
+}
+
+func (s *confirmServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *confirmServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *confirmServ) Describe() api.Command {
+	return api.Command{Name: confirmKey, Aliases: []string{"CONFIRM"}, Description: "cmd.confirm.desc"}
+}
+
+// parseCaseTemplate reads the lines after "开工单" as "key: value" fields,
+// full-width colons and the English keys are accepted, the fields are keyed
+// by the Chinese keys. Lines without a key continue the previous
+// field, a first line without a key is the title. It reports false when no
+// line has a key, the text is then a plain title.
+func parseCaseTemplate(text string) (map[string]string, bool) {
+	fields := map[string]string{}
+	found := false
+	current := "问题"
+	for _, line := range strings.Split(text, "\n") {
+		if key, value, ok := templateLine(line); ok {
+			current = key
+			fields[key] = value
+			found = true
+			continue
+		}
+		if strings.TrimSpace(line) == "" && current != "内容" {
+			continue
+		}
+		if v, ok := fields[current]; ok {
+			fields[current] = v + "\n" + line
+		} else {
+			fields[current] = strings.TrimSpace(line)
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	for k, v := range fields {
+		fields[k] = strings.TrimSpace(v)
+	}
+	return fields, true
+}
+
+func templateLine(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(strings.Replace(line, "：", ":", 1), ":")
+	if !ok {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if k, ok := caseTemplateAliases[strings.ToLower(key)]; ok {
+		key = k
+	}
+	if _, ok := caseTemplateFields[key]; !ok {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// applyCaseTemplate fills the draft from the template fields and lists every
+// field that is missing or not valid for the user.
+func applyCaseTemplate(c *dao.Case, fields map[string]string) error {
+	l := dao.CaseLocale(c)
+	var problems []string
+	for _, f := range caseTemplateRequired {
+		if fields[f] == "" {
+			problems = append(problems, i18n.T(l, "template.missing", i18n.T(l, caseTemplateFields[f])))
+		}
+	}
+	card := &c.CardMsg.Card
+
+	c.Content = fields["内容"]
+	if i := cardElement(card, contentKey); i >= 0 {
+		card.Elements[i].Content += c.Content
+	}
+
+	if v := fields["账户"]; v != "" {
+		key, err := templateAccount(c.UserID, v)
+		if err != nil {
+			problems = append(problems, i18n.Message(l, err))
+		} else {
+			c.AccountKey = key
+			if i := cardElement(card, openCaseAccountKey); i >= 0 {
+				card.Elements[i].Extra.InitialOption = key
+			}
+		}
+	}
+
+	// services and severities are checked against the account of the
+	// template only, a missing or unknown account is reported above
+	if c.AccountKey == "" {
+		return templateProblems(problems)
+	}
+	catalog, err := caseCatalog(c)
+	if err != nil {
+		problems = append(problems, i18n.Message(l, err))
+	} else {
+		setServiceOptions(c, catalog, issueServices(c, catalog))
+		if v := fields["服务"]; v != "" {
+			if err = templateService(c, catalog, v, fields["类别"]); err != nil {
+				problems = append(problems, i18n.Message(l, err))
+			}
+		}
+	}
+
+	levels, err := caseSeverities(c)
+	if err != nil {
+		problems = append(problems, i18n.Message(l, err))
+	} else {
+		refreshSeverities(c, levels)
+		if v := fields["响应速度"]; v != "" {
+			code, ok := templateSeverity(c, levels, v)
+			if !ok {
+				problems = append(problems, i18n.T(l, "template.severity_unsupported", v))
+			} else {
+				c.SevCode = code
+				if i := cardElement(card, sevKey); i >= 0 {
+					card.Elements[i].Extra.InitialOption = code
+				}
+			}
+		}
+	}
+
+	return templateProblems(problems)
+}
+
+func templateProblems(problems []string) error {
+	if len(problems) > 0 {
+		return i18n.Errorf("template.problems", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// templateAccount finds the account by key, name or account id among the
+// accounts the user may use.
+func templateAccount(userID, v string) (string, error) {
+	accounts, err := dao.AllowedAccounts(userID)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range accounts {
+		a, ok := config.Get().Accounts[key]
+		if key == v || ok && a != nil && (dao.GetAccountName(key, a) == v || a.RoleARN != "" && dao.GetAccountIdFromRoleARN(a.RoleARN) == v) {
+			return key, nil
+		}
+	}
+	return "", i18n.Errorf("template.account_unknown", v)
+}
+
+// templateService chooses the service by code, name or a keyword matching a
+// single service, and its category.
+func templateService(c *dao.Case, catalog *dao.ServiceCatalog, v, category string) error {
+	services := &dao.ServiceCatalog{Services: issueServices(c, catalog)}
+	service, ok := services.Service(v)
+	if !ok {
+		matches := services.Search(v)
+		for i := range matches {
+			if strings.EqualFold(matches[i].Code, v) || strings.EqualFold(matches[i].Name, v) {
+				matches = matches[i : i+1]
+				break
+			}
+		}
+		if len(matches) != 1 {
+			return i18n.Errorf("template.service_unknown", v)
+		}
+		service = &matches[0]
+	}
+	setServiceOptions(c, catalog, services.Services)
+	selectService(c, service)
+	if category == "" {
+		return nil
+	}
+	cat, ok := service.Category(category)
+	if !ok {
+		return i18n.Errorf("category.unknown", service.Name, category)
+	}
+	c.CategoryCode = cat.Code
+	if i := cardElement(&c.CardMsg.Card, categoryKey); i >= 0 {
+		c.CardMsg.Card.Elements[i].Extra.InitialOption = cat.Code
+	}
+	return nil
+}
+
+// templateSeverity finds the severity by code, name or its label on the card.
+func templateSeverity(c *dao.Case, levels []dao.SeverityLevel, v string) (string, bool) {
+	for _, l := range levels {
+		if strings.EqualFold(l.Code, v) || strings.EqualFold(l.Name, v) {
+			return l.Code, true
+		}
+	}
+	if i := cardElement(&c.CardMsg.Card, sevKey); i >= 0 {
+		for _, opt := range c.CardMsg.Card.Elements[i].Extra.Options {
+			if opt.Text.Content == v {
+				return opt.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// missingFields returns the template keys of the fields the draft still
+// needs before the case can be created.
+func missingFields(c *dao.Case) []string {
+	var missing []string
+	check := func(name, v string) {
+		if strings.TrimSpace(v) == "" {
+			missing = append(missing, name)
+		}
+	}
+	check("问题", c.Title)
+	if !hasCaseBody(c) {
+		missing = append(missing, "内容")
+	}
+	check("账户", c.AccountKey)
+	check("服务", c.ServiceCode)
+	check("类别", c.CategoryCode)
+	check("响应速度", c.SevCode)
+	return missing
+}
+
+// fieldNames are the names of the template keys in the locale.
+func fieldNames(l i18n.Locale, keys []string) []string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = i18n.T(l, caseTemplateFields[k])
+	}
+	return names
+}
+
+func confirmElement(l i18n.Locale) model.Elements {
+	return model.Elements{
+		Tag: "action",
+		Actions: []model.Button{
+			{
+				Tag:   "button",
+				Text:  model.Text{Tag: "plain_text", Content: i18n.T(l, "card.confirm")},
+				Type:  "primary",
+				Value: map[string]string{"key": confirmKey},
+			},
+		},
+		Extra: model.Extra{Value: model.Value{Key: confirmKey}},
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"msg-event/dao"
+	"reflect"
+	"testing"
+)
+
+func TestParseCaseTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   map[string]string
+		wantOK bool
+	}{
+		{
+			name: "plain title",
+			text: "EC2 无法启动",
+		},
+		{
+			name: "title with a colon in english prose",
+			text: "Note: EC2 does not start",
+		},
+		{
+			name: "chinese keys with full-width colons",
+			text: "问题：EC2 无法启动\n内容：实例 i-123 启动失败\n账户：prod\n服务：EC2\n响应速度：high",
+			want: map[string]string{
+				"问题": "EC2 无法启动", "内容": "实例 i-123 启动失败", "账户": "prod", "服务": "EC2", "响应速度": "high",
+			},
+			wantOK: true,
+		},
+		{
+			name: "english keys ignore case",
+			text: "Subject: EC2 does not start\nDESCRIPTION: i-123 fails\nAccount: prod\nservice: ec2\nCategory: other\nSeverity: low",
+			want: map[string]string{
+				"问题": "EC2 does not start", "内容": "i-123 fails", "账户": "prod", "服务": "ec2", "类别": "other", "响应速度": "low",
+			},
+			wantOK: true,
+		},
+		{
+			name:   "first line without key is the title",
+			text:   "EC2 无法启动\n账户: prod",
+			want:   map[string]string{"问题": "EC2 无法启动", "账户": "prod"},
+			wantOK: true,
+		},
+		{
+			name: "content continues over lines and keeps blank lines",
+			text: "问题: EC2\n内容: line one\n\nline two\nRegion: us-east-1\n服务: EC2",
+			want: map[string]string{
+				"问题": "EC2", "内容": "line one\n\nline two\nRegion: us-east-1", "服务": "EC2",
+			},
+			wantOK: true,
+		},
+		{
+			name:   "empty value and trailing blank lines",
+			text:   "问题: EC2\n账户:\n\n",
+			want:   map[string]string{"问题": "EC2", "账户": ""},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseCaseTemplate(tt.text)
+			if ok != tt.wantOK {
+				t.Fatalf("template %v, want %v", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantKey   string
+		wantValue string
+		wantOK    bool
+	}{
+		{line: "问题: EC2", wantKey: "问题", wantValue: "EC2", wantOK: true},
+		{line: "  内容 ： 启动失败 ", wantKey: "内容", wantValue: "启动失败", wantOK: true},
+		{line: "Title:EC2: no boot", wantKey: "问题", wantValue: "EC2: no boot", wantOK: true},
+		{line: "SEVERITY: urgent", wantKey: "响应速度", wantValue: "urgent", wantOK: true},
+		{line: "Region: us-east-1"},
+		{line: "No colon here"},
+		{line: "问题"},
+		{line: ": value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, value, ok := templateLine(tt.line)
+			if key != tt.wantKey || value != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("got %q %q %v, want %q %q %v", key, value, ok, tt.wantKey, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	complete := func() *dao.Case {
+		return &dao.Case{Title: "EC2", Content: "fails", AccountKey: "prod", ServiceCode: "amazon-ec2",
+			CategoryCode: "other", SevCode: "low", IssueType: dao.ISSUE_TECHNICAL}
+	}
+	tests := []struct {
+		name   string
+		change func(c *dao.Case)
+		want   []string
+	}{
+		{name: "complete", change: func(c *dao.Case) {}},
+		{name: "blank title and content", change: func(c *dao.Case) { c.Title, c.Content = "  ", " " }, want: []string{"问题", "内容"}},
+		{name: "no category", change: func(c *dao.Case) { c.CategoryCode = "" }, want: []string{"类别"}},
+		{name: "nothing chosen", change: func(c *dao.Case) { c.AccountKey, c.ServiceCode, c.CategoryCode, c.SevCode = "", "", "", "" },
+			want: []string{"账户", "服务", "类别", "响应速度"}},
+		{name: "limit increase without limit", change: func(c *dao.Case) { c.IssueType = dao.ISSUE_LIMIT }, want: []string{"内容"}},
+		{name: "limit increase", change: func(c *dao.Case) {
+			c.IssueType, c.Content = dao.ISSUE_LIMIT, ""
+			c.Limit = &dao.LimitRequest{Quota: "L-1216C47A", Region: "us-east-1", Value: "256"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := complete()
+			tt.change(c)
+			if got := missingFields(c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missing %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
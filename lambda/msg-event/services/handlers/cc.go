@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ccKey = "cc"
+	// maxCCEmails is the most CC emails CreateCase accepts
+	maxCCEmails = 10
+)
+
+var errCCUsage = i18n.Errorf("cc.usage")
+
+type ccServ struct {
+}
+
+func GetCCServ() api.Server {
+	return &ccServ{}
+}
+
+// Handle edits the CC emails. On a draft "抄送 a@example.com,b@example.com"
+// replaces the list, a leading + adds and a leading - removes emails. In a
+// case chat the emails are added to the open case, AWS can not remove them.
+func (s *ccServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCaseByEvent(e)
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	arg := strings.Trim(str, " ")
+	op := ""
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		op, arg = arg[:1], arg[1:]
+	}
+	emails, err := parseEmails(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Type == dao.TYPE_CASE {
+		if op == "-" {
+			return nil, i18n.Errorf("cc.remove_opened")
+		}
+		added := subtractEmails(emails, c.CcEmails)
+		if len(added) == 0 {
+			return nil, i18n.Errorf("cc.already")
+		}
+		if len(added) > maxCCEmails {
+			return nil, i18n.Errorf("cc.too_many_added", maxCCEmails)
+		}
+		if err = dao.AddCCEmails(c, added); err != nil {
+			return nil, accountErr(err)
+		}
+		c.CcEmails = append(c.CcEmails, added...)
+		if _, err = dao.UpsertCase(c); err != nil {
+			return nil, err
+		}
+		_, err = dao.SendMsgToChannel(c.ChannelID, i18n.T(locale(e), "cc.added", strings.Join(added, ", ")))
+		return c, err
+	}
+
+	switch op {
+	case "+":
+		emails = append(c.CcEmails, subtractEmails(emails, c.CcEmails)...)
+	case "-":
+		emails = subtractEmails(c.CcEmails, emails)
+	}
+	if len(emails) > maxCCEmails {
+		return nil, i18n.Errorf("cc.too_many", maxCCEmails)
+	}
+	c.CcEmails = emails
+	if i := cardElement(&c.CardMsg.Card, ccKey); i >= 0 {
+		c.CardMsg.Card.Elements[i] = ccElement(c)
+	}
+	c.UpdateTime = time.Now().String()
+	return replyCard(c)
+}
+
+func (s *ccServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *ccServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *ccServ) Describe() api.Command {
+	return api.Command{Name: "抄送", Aliases: []string{"CC"}, Syntax: "cmd.cc.syntax", Description: "cmd.cc.desc"}
+}
+
+// defaultCCEmails copies the members of the group on the case.
+func defaultCCEmails(chatID string) []string {
+	emails, err := dao.GetChatMemberEmails(chatID)
+	if err != nil {
+		logrus.Warnf("failed to get member emails of chat %s, %v", chatID, err)
+	}
+	if len(emails) > maxCCEmails {
+		emails = emails[:maxCCEmails]
+	}
+	return emails
+}
+
+func parseEmails(list string) ([]string, error) {
+	var emails []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.Trim(item, " ")
+		if item == "" {
+			continue
+		}
+		if !isEmail(item) {
+			return nil, i18n.Errorf("cc.invalid", item)
+		}
+		emails = append(emails, item)
+	}
+	if len(emails) == 0 {
+		return nil, errCCUsage
+	}
+	return emails, nil
+}
+
+// subtractEmails returns the emails of a that are not in b, ignoring case.
+func subtractEmails(a, b []string) []string {
+	var rest []string
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			found = found || strings.EqualFold(x, y)
+		}
+		if !found {
+			rest = append(rest, x)
+		}
+	}
+	return rest
+}
+
+func ccElement(c *dao.Case) model.Elements {
+	l := dao.CaseLocale(c)
+	list := i18n.T(l, "cc.none")
+	if len(c.CcEmails) > 0 {
+		list = strings.Join(c.CcEmails, ", ")
+	}
+	return model.Elements{
+		Tag:     "markdown",
+		Content: i18n.T(l, "card.cc", list),
+		Extra:   model.Extra{Value: model.Value{Key: ccKey}},
+	}
+}
@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"errors"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
 	"msg-event/model/event"
 	"msg-event/services/api"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -22,11 +23,11 @@ func (s *commentsServ) Handle(e *event.Msg, str string) (c *dao.Case, err error)
 	c, err = dao.GetCaseByEvent(e)
 	if err != nil {
 		logrus.Errorf("get case failed %+v", err)
-		return nil, errors.New(config.CaseNotExisted)
+		return nil, i18n.Errorf("case.not_opened")
 	}
 	cazeID := strings.Trim(c.CaseID, " ")
 	if cazeID == "" || c.Type == dao.TYPE_OPEN_CASE {
-		return nil, errors.New(dao.FormatMsg(c))
+		return nil, i18n.Errorf("case.incomplete")
 	}
 
 	// add comment to aws case system
@@ -36,10 +37,11 @@ func (s *commentsServ) Handle(e *event.Msg, str string) (c *dao.Case, err error)
 		return nil, err
 	}
 
-	dao.SendMsg(c.ChannelID, c.UserID, config.Conf.Ack)
+	dao.SendMsg(c.ChannelID, c.UserID, i18n.T(dao.CaseLocale(c), "comment.ack"))
 
 	if c.Status == dao.STATUS_CLOSE {
 		c.Status = dao.STATUS_OPEN
+		c.ClosedAt = time.Time{}
 		logrus.Infof("change the case status to OPEN for re-open case %v", c.Status)
 	}
 
@@ -51,3 +53,11 @@ func (s *commentsServ) Handle(e *event.Msg, str string) (c *dao.Case, err error)
 func (s *commentsServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *commentsServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *commentsServ) Describe() api.Command {
+	return api.Command{Description: "cmd.comment.desc"}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"msg-event/config"
 	"msg-event/dao"
 	"msg-event/model/event"
 	"msg-event/services/api"
@@ -45,3 +46,14 @@ func (s *contentServ) Handle(e *event.Msg, content string) (c *dao.Case, err err
 func (s *contentServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *contentServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *contentServ) Describe() api.Command {
+	return api.Command{
+		Name: "内容", Aliases: []string{"DESCRIPTION"}, Syntax: "cmd.content.syntax",
+		Description: "cmd.content.desc",
+	}
+}
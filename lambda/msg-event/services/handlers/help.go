@@ -1,33 +1,82 @@
 package handlers
 
 import (
+	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
-
-	"github.com/sirupsen/logrus"
+	"strings"
 )
 
 type helper struct {
+	commands func() []api.Server
 }
 
-func Gethelper() api.Server {
-	return &helper{}
+// Gethelper returns the help command, commands lists the registered servers
+// when the help card is rendered.
+func Gethelper(commands func() []api.Server) api.Server {
+	return &helper{commands: commands}
 }
 
+// Handle sends the help card with the commands the caller may use.
 func (h *helper) Handle(e *event.Msg, title string) (c *dao.Case, err error) {
-	caze, err := dao.GetCaseByEvent(e)
-	if err != nil {
-		return nil, err
-	}
-	rsp, err := dao.SendCardMsg(caze.CardMsg, caze)
-	if err != nil {
-		logrus.Errorf("send card msg failed, %v", err)
-	}
-	caze.CardRespMsgID = *rsp.Data.MessageId
-	return dao.UpsertCase(caze)
+	_, err = dao.SendCardMsg(&model.FeiShuMsg{
+		ChatId: e.ChatID(),
+		Card:   *helpCard(locale(e), h.commands(), dao.GetUserRole(e.Operator())),
+	}, nil)
+	return nil, err
 }
 
 func (s *helper) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *helper) RequiredRole() config.Role {
+	return config.RoleViewer
+}
+
+func (s *helper) Describe() api.Command {
+	return api.Command{Name: "帮助", Aliases: []string{"HELP"}, Description: "cmd.help.desc"}
+}
+
+func helpCard(l i18n.Locale, servers []api.Server, role config.Role) *model.Card {
+	var lines []string
+	for _, s := range servers {
+		c := s.Describe()
+		if c.Hidden || !role.Covers(s.RequiredRole()) {
+			continue
+		}
+		line := "**" + c.Name + "**"
+		if c.Syntax != "" {
+			line += " " + i18n.T(l, c.Syntax)
+		}
+		if len(c.Aliases) > 0 {
+			line += i18n.T(l, "help.aliases", strings.Join(c.Aliases, i18n.T(l, "list.separator")))
+		}
+		if s.RequiredRole() != config.RoleViewer && s.RequiredRole() != config.RoleSubmitter {
+			line += i18n.T(l, "help.role", dao.RoleName(l, s.RequiredRole()))
+		}
+		lines = append(lines, line+"\n"+i18n.T(l, c.Description))
+	}
+	if role.Covers(GetCommentsServServ().RequiredRole()) {
+		lines = append(lines, i18n.T(l, GetCommentsServServ().Describe().Description))
+	}
+
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "help.title")},
+			Template: "blue",
+		},
+		Elements: []model.Elements{
+			{
+				Tag:     "markdown",
+				Content: i18n.T(l, "help.intro", dao.RoleName(l, role)),
+			},
+			{Tag: "hr"},
+			{Tag: "markdown", Content: strings.Join(lines, "\n\n")},
+		},
+	}
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"fmt"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	issueTypeKey = "类型"
+	limitKey     = "limit"
+	// limitServiceCode is the service of limit increase cases, its categories
+	// are the services the quota belongs to
+	limitServiceCode = "service-limit-increase"
+)
+
+// billingServiceCodes are the services offered for account and billing cases.
+var billingServiceCodes = map[string]bool{
+	"account-management": true,
+	"billing":            true,
+	"customer-account":   true,
+}
+
+// issueTypeNames are the issue types and the catalog keys of their names.
+var issueTypeNames = []struct {
+	Code string
+	Key  string
+}{
+	{dao.ISSUE_TECHNICAL, "issue_type.technical"},
+	{dao.ISSUE_BILLING, "issue_type.billing"},
+	{dao.ISSUE_LIMIT, "issue_type.limit"},
+}
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+var errNotLimitCase = i18n.Errorf("limit.not_limit_case")
+
+type issueTypeServ struct {
+}
+
+// limitServ fills a field of the service limit increase form.
+type limitServ struct {
+	field string
+}
+
+func GetIssueTypeServ() api.Server {
+	return &issueTypeServ{}
+}
+
+func GetQuotaServ() api.Server {
+	return &limitServ{field: "quota"}
+}
+
+func GetRegionServ() api.Server {
+	return &limitServ{field: "region"}
+}
+
+func GetRequestedValueServ() api.Server {
+	return &limitServ{field: "value"}
+}
+
+// Handle switches the draft to the issue type, by code from the card or by
+// code or name in any locale as a text command. The fields of the previous type are
+// cleared.
+func (s *issueTypeServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCaseByEvent(e)
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	t := strings.Trim(str, " ")
+	code := ""
+	for _, it := range issueTypeNames {
+		if t == it.Code || t == i18n.T(i18n.ZhCN, it.Key) || strings.EqualFold(t, i18n.T(i18n.EnUS, it.Key)) {
+			code = it.Code
+		}
+	}
+	if code == "" {
+		return nil, i18n.Errorf("issue_type.unknown", t)
+	}
+
+	c.IssueType = code
+	c.ServiceCode = ""
+	c.CategoryCode = ""
+	c.Limit = nil
+	if code == dao.ISSUE_LIMIT {
+		c.Limit = &dao.LimitRequest{}
+	}
+	// without an account the fields are shown once it is chosen
+	if c.AccountKey != "" {
+		catalog, err := caseCatalog(c)
+		if err != nil {
+			return nil, err
+		}
+		if err = applyIssueType(c, catalog); err != nil {
+			return nil, err
+		}
+	}
+	if i := cardElement(&c.CardMsg.Card, issueTypeKey); i >= 0 {
+		c.CardMsg.Card.Elements[i].Extra.InitialOption = code
+	}
+	c.UpdateTime = time.Now().String()
+	if e.Action != nil {
+		return dao.UpsertCase(c)
+	}
+	return replyCard(c)
+}
+
+func (s *issueTypeServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *issueTypeServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *issueTypeServ) Describe() api.Command {
+	return api.Command{Name: issueTypeKey, Aliases: []string{"ISSUE_TYPE"}, Syntax: "cmd.issue_type.syntax", Description: "cmd.issue_type.desc"}
+}
+
+// Handle sets the quota, the region or the requested value, e.g.
+// "区域 us-east-1".
+func (s *limitServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCaseByEvent(e)
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	if issueType(c) != dao.ISSUE_LIMIT {
+		return nil, errNotLimitCase
+	}
+	if c.Limit == nil {
+		c.Limit = &dao.LimitRequest{}
+	}
+
+	v := strings.Trim(str, " ")
+	switch s.field {
+	case "quota":
+		if v == "" {
+			return nil, i18n.Errorf("limit.quota_usage")
+		}
+		c.Limit.Quota = v
+	case "region":
+		if !regionPattern.MatchString(v) {
+			return nil, i18n.Errorf("limit.invalid_region", v)
+		}
+		c.Limit.Region = v
+	case "value":
+		if n, err := strconv.ParseFloat(v, 64); err != nil || n <= 0 {
+			return nil, i18n.Errorf("limit.invalid_value", v)
+		}
+		c.Limit.Value = v
+	}
+
+	if i := cardElement(&c.CardMsg.Card, limitKey); i >= 0 {
+		c.CardMsg.Card.Elements[i] = limitElement(c)
+	}
+	c.UpdateTime = time.Now().String()
+	return replyCard(c)
+}
+
+func (s *limitServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *limitServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *limitServ) Describe() api.Command {
+	switch s.field {
+	case "quota":
+		return api.Command{Name: "配额", Aliases: []string{"QUOTA"}, Syntax: "cmd.quota.syntax", Description: "cmd.quota.desc"}
+	case "region":
+		return api.Command{Name: "区域", Aliases: []string{"REGION"}, Syntax: "cmd.region.syntax", Description: "cmd.region.desc"}
+	default:
+		return api.Command{Name: "申请值", Aliases: []string{"REQUESTED_VALUE"}, Syntax: "cmd.requested_value.syntax", Description: "cmd.requested_value.desc"}
+	}
+}
+
+func issueType(c *dao.Case) string {
+	if c.IssueType == "" {
+		return dao.ISSUE_TECHNICAL
+	}
+	return c.IssueType
+}
+
+func issueTypeName(l i18n.Locale, c *dao.Case) string {
+	t := issueType(c)
+	for _, it := range issueTypeNames {
+		if it.Code == t {
+			return i18n.T(l, it.Key)
+		}
+	}
+	return t
+}
+
+// issueServices returns the services a case of the issue type may choose,
+// limit increase cases have their service fixed.
+func issueServices(c *dao.Case, catalog *dao.ServiceCatalog) []dao.CatalogService {
+	t := issueType(c)
+	if t == dao.ISSUE_LIMIT {
+		return nil
+	}
+	var services []dao.CatalogService
+	for _, s := range catalog.Services {
+		if s.Code == limitServiceCode {
+			continue
+		}
+		if billingServiceCodes[s.Code] == (t == dao.ISSUE_BILLING) {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// applyIssueType shows the fields of the issue type on the card. Limit
+// increase cases get the quota services and the form instead of the service
+// selector.
+func applyIssueType(c *dao.Case, catalog *dao.ServiceCatalog) error {
+	card := &c.CardMsg.Card
+	l := dao.CaseLocale(c)
+	removeCardElement(card, categoryKey)
+	removeCardElement(card, limitKey)
+
+	if issueType(c) == dao.ISSUE_LIMIT {
+		removeCardElement(card, serviceKey)
+		limit, ok := catalog.Service(limitServiceCode)
+		if !ok {
+			return i18n.Errorf("limit.not_supported")
+		}
+		selectService(c, limit)
+		if i := cardElement(card, categoryKey); i >= 0 {
+			card.Elements[i].Text.Content = i18n.T(l, "card.limit_service")
+		}
+		insertCardElement(card, categoryKey, limitElement(c))
+		return nil
+	}
+
+	if cardElement(card, serviceKey) < 0 {
+		t := dao.CaseCardTemplate(l)
+		if t == nil || cardElement(&t.Card, serviceKey) < 0 {
+			return i18n.Errorf("card.no_service_selector")
+		}
+		service := t.Card.Elements[cardElement(&t.Card, serviceKey)]
+		insertCardElement(card, issueTypeKey, service)
+	}
+	if i := cardElement(card, serviceKey); i >= 0 {
+		card.Elements[i].Extra.InitialOption = ""
+	}
+	setServiceOptions(c, catalog, issueServices(c, catalog))
+	return nil
+}
+
+func issueTypeElement(l i18n.Locale) model.Elements {
+	opts := make([]model.Options, 0, len(issueTypeNames))
+	for _, it := range issueTypeNames {
+		opts = append(opts, model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: i18n.T(l, it.Key)},
+			Value: it.Code,
+		})
+	}
+	return model.Elements{
+		Tag:  "div",
+		Text: model.Text{Tag: "lark_md", Content: i18n.T(l, "card.issue_type")},
+		Extra: model.Extra{
+			Tag:           "select_static",
+			Placeholder:   model.Placeholder{Tag: "plain_text", Content: i18n.T(l, "card.issue_type.placeholder")},
+			Value:         model.Value{Key: issueTypeKey},
+			InitialOption: dao.ISSUE_TECHNICAL,
+			Options:       opts,
+		},
+	}
+}
+
+func limitElement(c *dao.Case) model.Elements {
+	limit := c.Limit
+	if limit == nil {
+		limit = &dao.LimitRequest{}
+	}
+	l := dao.CaseLocale(c)
+	value := func(s string) string {
+		if s == "" {
+			return i18n.T(l, "card.unfilled")
+		}
+		return s
+	}
+	return model.Elements{
+		Tag:     "markdown",
+		Content: i18n.T(l, "card.limit", value(limit.Quota), value(limit.Region), value(limit.Value)),
+		Extra:   model.Extra{Value: model.Value{Key: limitKey}},
+	}
+}
+
+// hasCaseBody reports whether the draft has what the first communication
+// of its issue type needs, the limit form or the content.
+func hasCaseBody(c *dao.Case) bool {
+	if issueType(c) == dao.ISSUE_LIMIT {
+		return c.Limit != nil && c.Limit.Quota != "" && c.Limit.Region != "" && c.Limit.Value != ""
+	}
+	return strings.Trim(c.Content, " ") != ""
+}
+
+// limitBody is the communication body of a limit increase case.
+func limitBody(c *dao.Case) string {
+	body := fmt.Sprintf("Service: %s\nQuota: %s\nRegion: %s\nRequested value: %s",
+		c.CategoryCode, c.Limit.Quota, c.Limit.Region, c.Limit.Value)
+	if strings.Trim(c.Content, " ") != "" {
+		body += "\n\n" + c.Content
+	}
+	return body
+}
+
+// insertCardElement puts the element right below the element with the key,
+// at the end when the card does not have it.
+func insertCardElement(card *model.Card, afterKey string, element model.Elements) {
+	i := cardElement(card, afterKey) + 1
+	if i == 0 {
+		i = len(card.Elements)
+	}
+	card.Elements = append(card.Elements[:i:i], append([]model.Elements{element}, card.Elements[i:]...)...)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const languageKey = "语言"
+
+// caseLanguages are the languages AWS Support handles cases in.
+var caseLanguages = []struct {
+	Code string
+	Name string
+}{
+	{"zh", "中文"},
+	{"ja", "日本語"},
+	{"ko", "한국어"},
+	{"en", "English"},
+}
+
+type languageServ struct {
+}
+
+func GetLanguageServ() api.Server {
+	return &languageServ{}
+}
+
+// Handle sets the language the case is opened in. Services and severities
+// are listed again in the language.
+func (s *languageServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCaseByEvent(e)
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	l := strings.Trim(str, " ")
+	code := ""
+	for _, cl := range caseLanguages {
+		if strings.EqualFold(l, cl.Code) || l == cl.Name {
+			code = cl.Code
+		}
+	}
+	if code == "" {
+		return nil, i18n.Errorf("language.unsupported", l)
+	}
+
+	c.Language = code
+	if c.AccountKey != "" {
+		catalog, err := caseCatalog(c)
+		if err != nil {
+			return nil, err
+		}
+		if err = refreshServices(c, catalog); err != nil {
+			return nil, err
+		}
+		levels, err := caseSeverities(c)
+		if err != nil {
+			return nil, err
+		}
+		refreshSeverities(c, levels)
+	}
+	if i := cardElement(&c.CardMsg.Card, languageKey); i >= 0 {
+		c.CardMsg.Card.Elements[i].Extra.InitialOption = code
+	}
+	c.UpdateTime = time.Now().String()
+	if e.Action != nil {
+		return dao.UpsertCase(c)
+	}
+	return replyCard(c)
+}
+
+func (s *languageServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *languageServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *languageServ) Describe() api.Command {
+	return api.Command{Name: languageKey, Aliases: []string{"LANGUAGE"}, Syntax: "cmd.language.syntax", Description: "cmd.language.desc"}
+}
+
+func languageElement(l i18n.Locale) model.Elements {
+	opts := make([]model.Options, 0, len(caseLanguages))
+	for _, cl := range caseLanguages {
+		opts = append(opts, model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: cl.Name},
+			Value: cl.Code,
+		})
+	}
+	return model.Elements{
+		Tag:  "div",
+		Text: model.Text{Tag: "lark_md", Content: i18n.T(l, "card.language")},
+		Extra: model.Extra{
+			Tag:           "select_static",
+			Placeholder:   model.Placeholder{Tag: "plain_text", Content: i18n.T(l, "card.language.placeholder")},
+			Value:         model.Value{Key: languageKey},
+			InitialOption: dao.CaseLanguage(),
+			Options:       opts,
+		},
+	}
+}
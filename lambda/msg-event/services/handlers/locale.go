@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model/event"
+	"msg-event/services/api"
+
+	"github.com/sirupsen/logrus"
+)
+
+const localeKey = "界面语言"
+
+type localeServ struct {
+}
+
+func GetLocaleServ() api.Server {
+	return &localeServ{}
+}
+
+// Handle sets the locale of the replies of the bot. In the chat with the bot
+// it is the locale of the user, in a group the locale of the chat, which only
+// admins may change.
+func (s *localeServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	l, ok := i18n.Parse(str)
+	if !ok {
+		return nil, i18n.Errorf("locale.usage")
+	}
+	chatID, userID := e.ChatID(), e.Operator()
+	if e.Event.Message.ChatType == "p2p" {
+		err = dao.SetUserLocale(userID, l)
+	} else {
+		if dao.CheckPermission(userID, config.RoleAdmin) != nil {
+			return nil, dao.SendNoPermissionCard(chatID, userID, config.RoleAdmin)
+		}
+		err = dao.SetChatLocale(chatID, l)
+	}
+	if err != nil {
+		logrus.Errorf("failed to set locale %s in chat %s, %v", l, chatID, err)
+		return nil, i18n.Errorf("locale.failed")
+	}
+	_, err = dao.SendMsgToChannel(chatID, i18n.T(l, "locale.set"))
+	return nil, err
+}
+
+func (s *localeServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *localeServ) RequiredRole() config.Role {
+	return config.RoleViewer
+}
+
+func (s *localeServ) Describe() api.Command {
+	return api.Command{Name: localeKey, Aliases: []string{"LOCALE"}, Syntax: "cmd.locale.syntax", Description: "cmd.locale.desc"}
+}
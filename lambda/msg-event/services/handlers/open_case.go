@@ -3,13 +3,23 @@ package handlers
 import (
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
 
 	"github.com/sirupsen/logrus"
 )
 
-const openCaseTitleKey = "title"
+var (
+	errNoAccount     = i18n.Errorf("account.none")
+	errChooseAccount = i18n.Errorf("account.choose_first")
+)
+
+const (
+	openCaseTitleKey   = "title"
+	openCaseAccountKey = "账户"
+)
 
 type openCaseServ struct {
 }
@@ -18,19 +28,46 @@ func GetOpenCaseServ() api.Server {
 	return &openCaseServ{}
 }
 
+// Handle sends the draft card in the locale of the user. A multi-line
+// template with "问题:", "内容:", "账户:", "服务:" and "响应速度:" lines, or
+// their English keys, fills the whole draft, it is created after the user
+// confirms it on the card.
 func (s *openCaseServ) Handle(e *event.Msg, title string) (c *dao.Case, err error) {
+	fields, isTemplate := parseCaseTemplate(title)
+	if isTemplate {
+		title = fields["问题"]
+	}
 	fromChannelID := e.Event.Message.ChatID
 	customerID := e.Event.Sender.SenderIDs.UserID
-	config.Conf.CaseCardTemplate.ChatId = fromChannelID
-	config.Conf.CaseCardTemplate.UserId = customerID
-
+	l := locale(e)
 	c = &dao.Case{
-		Title: title,
+		Title:  title,
+		Locale: string(l),
+		UserID: customerID,
 	}
 
-	for i, element := range config.Conf.CaseCardTemplate.Card.Elements {
+	accounts, err := dao.AllowedAccounts(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, errNoAccount
+	}
+	if err = newCaseCard(c, fromChannelID, accounts); err != nil {
+		return nil, err
+	}
+	cardMsg := c.CardMsg
+	if isTemplate {
+		if err = applyCaseTemplate(c, fields); err != nil {
+			return nil, err
+		}
+		c.Confirming = true
+		cardMsg.Card.Elements = append(cardMsg.Card.Elements, confirmElement(l))
+	}
+
+	for i, element := range cardMsg.Card.Elements {
 		if element.Extra.Value.Key == openCaseTitleKey {
-			config.Conf.CaseCardTemplate.Card.Elements[i].Content += title
+			cardMsg.Card.Elements[i].Content += title
 			logrus.Infof("match key %v. value %v", openCaseTitleKey, title)
 			break
 		} else {
@@ -38,15 +75,85 @@ func (s *openCaseServ) Handle(e *event.Msg, title string) (c *dao.Case, err erro
 		}
 	}
 
-	rsp, err := dao.SendCardMsg(config.Conf.CaseCardTemplate, c)
+	rsp, err := dao.SendCardMsg(cardMsg, c)
 	if err != nil {
 		logrus.Errorf("Failed to send card msg, %v", err)
 		return nil, err
 	}
-	return dao.OpenCase(fromChannelID, customerID, title, *rsp.Data.MessageId, config.Conf.CaseCardTemplate)
+	return dao.OpenCase(c, fromChannelID, customerID, *rsp.Data.MessageId, cardMsg)
 
 }
 
 func (s *openCaseServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *openCaseServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *openCaseServ) Describe() api.Command {
+	return api.Command{
+		Name: "开工单", Aliases: []string{"SUBJECT"}, Syntax: "cmd.open_case.syntax",
+		Description: "cmd.open_case.desc",
+	}
+}
+
+// newCaseCard sets the draft card of the case in its locale, the case form
+// sends the same card to the case group. A single account is chosen at once,
+// else services and severities are listed once the user chose the account.
+func newCaseCard(c *dao.Case, chatID string, accounts []string) error {
+	l := dao.CaseLocale(c)
+	cardMsg := dao.CaseCardTemplate(l).Clone()
+	cardMsg.ChatId = chatID
+	cardMsg.UserId = c.UserID
+	if len(accounts) == 1 {
+		c.AccountKey = accounts[0]
+	}
+	for i, element := range cardMsg.Card.Elements {
+		if element.Extra.Value.Key == openCaseAccountKey {
+			cardMsg.Card.Elements[i].Extra.Options = accountOptions(accounts)
+			cardMsg.Card.Elements[i].Extra.InitialOption = c.AccountKey
+		}
+	}
+	// the issue type is asked first, technical until another is chosen
+	issue := cardElement(&cardMsg.Card, openCaseAccountKey)
+	if issue < 0 {
+		issue = cardElement(&cardMsg.Card, serviceKey)
+	}
+	if issue < 0 {
+		issue = len(cardMsg.Card.Elements)
+	}
+	elements := cardMsg.Card.Elements
+	cardMsg.Card.Elements = append(elements[:issue:issue], append([]model.Elements{issueTypeElement(l)}, elements[issue:]...)...)
+	c.CardMsg = cardMsg
+	if c.AccountKey == "" {
+		clearAccountOptions(c)
+	} else {
+		catalog, err := caseCatalog(c)
+		if err != nil {
+			return err
+		}
+		setServiceOptions(c, catalog, issueServices(c, catalog))
+		levels, err := caseSeverities(c)
+		if err != nil {
+			return err
+		}
+		refreshSeverities(c, levels)
+	}
+	c.CcEmails = defaultCCEmails(chatID)
+	insertCardElement(&cardMsg.Card, sevKey, languageElement(l))
+	insertCardElement(&cardMsg.Card, languageKey, ccElement(c))
+	return nil
+}
+
+// clearAccountOptions empties the service and severity selectors until an
+// account is chosen, the options of the template may not suit the account.
+func clearAccountOptions(c *dao.Case) {
+	for _, key := range []string{serviceKey, sevKey} {
+		if i := cardElement(&c.CardMsg.Card, key); i >= 0 {
+			c.CardMsg.Card.Elements[i].Extra.Options = []model.Options{}
+			c.CardMsg.Card.Elements[i].Extra.InitialOption = ""
+		}
+	}
+}
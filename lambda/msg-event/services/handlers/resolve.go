@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// resolveKey is the command, the button on the status card and the
+	// buttons of the confirm card
+	resolveKey      = "结案"
+	resolveCardName = "resolve"
+	resolveConfirm  = "confirm"
+	resolveCancel   = "cancel"
+)
+
+type resolveServ struct {
+}
+
+func GetResolveServ() api.Server {
+	return &resolveServ{}
+}
+
+// Handle sends the confirm card for the case of the group, the command and
+// the button of the status card both end up here. The buttons of the confirm
+// card come back with the op and the case is resolved on confirm.
+func (s *resolveServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCase(e.ChatID())
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	if c == nil || c.Type != dao.TYPE_CASE || c.CaseID == "" {
+		return nil, i18n.Errorf("resolve.not_case")
+	}
+	l := locale(e)
+	op := ""
+	if e.Action != nil && e.Action.Value != nil {
+		op = e.Action.Value.Op
+	}
+	switch op {
+	case resolveCancel:
+		return nil, dao.UpdateCardMsg(e.OpenMsgID, resolveResultCard(l, i18n.T(l, "resolve.canceled")))
+	case resolveConfirm:
+		return nil, resolveCase(e, c)
+	}
+	if c.Status == dao.STATUS_CLOSE {
+		return nil, i18n.Errorf("resolve.already", c.DisplayCaseID)
+	}
+	_, err = dao.SendCardMsg(&model.FeiShuMsg{ChatId: c.ChannelID, Card: *resolveConfirmCard(l, c)}, nil)
+	return nil, err
+}
+
+func (s *resolveServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *resolveServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *resolveServ) Describe() api.Command {
+	return api.Command{Name: resolveKey, Aliases: []string{"RESOLVE"}, Description: "cmd.resolve.desc"}
+}
+
+// resolveCase resolves the AWS case, records the statuses AWS reported and
+// posts the closing summary to the group.
+func resolveCase(e *event.Msg, c *dao.Case) error {
+	l := locale(e)
+	if c.Status == dao.STATUS_CLOSE {
+		return dao.UpdateCardMsg(e.OpenMsgID, resolveResultCard(l, i18n.T(l, "resolve.already", c.DisplayCaseID)))
+	}
+	initial, final, err := dao.ResolveCase(c)
+	if err != nil {
+		return accountErr(err)
+	}
+	operator := e.Operator()
+	c.Close()
+	c.ResolvedBy = operator
+	c.ResolveTime = time.Now().String()
+	c.ResolveInitialStatus = initial
+	c.ResolveFinalStatus = final
+	// the summary tells the group, no status notice
+	c.SetAWSStatus(final)
+	if c.CardMsg != nil {
+		removeCardElement(&c.CardMsg.Card, resolveKey)
+	}
+	if _, err = dao.UpsertCase(c); err != nil {
+		return err
+	}
+
+	if err = dao.UpdateCardMsg(e.OpenMsgID, resolveResultCard(l, i18n.T(l, "resolve.done", c.DisplayCaseID, operator))); err != nil {
+		logrus.Warnf("failed to update resolve card %s, %v", e.OpenMsgID, err)
+	}
+	if c.CardMsg != nil && c.CardRespMsgID != "" {
+		if err = dao.UpdateCardMsg(c.CardRespMsgID, &c.CardMsg.Card); err != nil {
+			logrus.Warnf("failed to update status card %s, %v", c.CardRespMsgID, err)
+		}
+	}
+	_, err = dao.SendCardMsg(&model.FeiShuMsg{ChatId: c.ChannelID, Card: *resolveSummaryCard(c)}, nil)
+	return err
+}
+
+// resolveElement is the resolve button of the status card in the case group.
+func resolveElement(l i18n.Locale) model.Elements {
+	return model.Elements{
+		Tag: "action",
+		Actions: []model.Button{
+			{
+				Tag:   "button",
+				Text:  model.Text{Tag: "plain_text", Content: i18n.T(l, "resolve.button")},
+				Type:  "danger",
+				Value: map[string]string{"key": resolveKey, "card": resolveCardName},
+			},
+		},
+		Extra: model.Extra{Value: model.Value{Key: resolveKey}},
+	}
+}
+
+func resolveConfirmCard(l i18n.Locale, c *dao.Case) *model.Card {
+	value := func(op string) map[string]string {
+		return map[string]string{"key": resolveKey, "card": resolveCardName, "op": op}
+	}
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "resolve.title")},
+			Template: "orange",
+		},
+		Elements: []model.Elements{
+			{
+				Tag:     "markdown",
+				Content: i18n.T(l, "resolve.confirm", c.DisplayCaseID, c.Title),
+			},
+			{
+				Tag: "action",
+				Actions: []model.Button{
+					{Tag: "button", Text: model.Text{Tag: "plain_text", Content: i18n.T(l, "resolve.confirm_button")}, Type: "danger", Value: value(resolveConfirm)},
+					{Tag: "button", Text: model.Text{Tag: "plain_text", Content: i18n.T(l, "resolve.cancel_button")}, Type: "default", Value: value(resolveCancel)},
+				},
+			},
+		},
+	}
+}
+
+func resolveResultCard(l i18n.Locale, text string) *model.Card {
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "resolve.title")},
+			Template: "grey",
+		},
+		Elements: []model.Elements{{Tag: "markdown", Content: text}},
+	}
+}
+
+// resolveSummaryCard is the closing summary posted to the case group.
+func resolveSummaryCard(c *dao.Case) *model.Card {
+	l := dao.CaseLocale(c)
+	return &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "resolve.summary_title")},
+			Template: "green",
+		},
+		Elements: []model.Elements{
+			{
+				Tag: "markdown",
+				Content: i18n.T(l, "resolve.summary", c.DisplayCaseID, c.Title,
+					statusName(l, c.ResolveInitialStatus), statusName(l, c.ResolveFinalStatus), c.ResolvedBy, formatTimestype(c.CreateTime), c.CaseURL, c.CaseURL),
+			},
+		},
+	}
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"fmt"
+	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
 	"msg-event/model/event"
 	"msg-event/services/api"
 	"regexp"
@@ -28,11 +30,16 @@ func (s *searcher) Handle(e *event.Msg, time string) (c *dao.Case, err error) {
 		return nil, err
 	}
 
-	title := "案例号\\t\\t\\t 账号\\t\\t\\t 创建时间\\t\\t\\t\\t 案例状态\\t\\t 题目 \\n"
+	l := locale(e)
+	title := i18n.T(l, "search.header")
 	for _, v := range cs {
 		// if v.Status != "NEW" && v.Status != "OPEN" {
 		if v.Status != "NEW" && v.Title != "" {
-			s := fmt.Sprintf("[%s](%s)\\t %s\\t %s\\t %s\\t\\t %s\\n", v.DisplayCaseID, v.CaseURL, v.CaseAccountID, formatTimestype(v.CreateTime), v.Status, v.Title)
+			status := v.Status
+			if v.AWSStatus != "" {
+				status = statusName(l, v.AWSStatus)
+			}
+			s := fmt.Sprintf("[%s](%s)\\t %s\\t %s\\t %s\\t\\t %s\\n", v.DisplayCaseID, v.CaseURL, v.CaseAccountID, formatTimestype(v.CreateTime), status, v.Title)
 			title += s
 		}
 
@@ -67,3 +74,11 @@ func formatTimestype(input string) string {
 func (s *searcher) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *searcher) RequiredRole() config.Role {
+	return config.RoleViewer
+}
+
+func (s *searcher) Describe() api.Command {
+	return api.Command{Name: "历史", Aliases: []string{"HISTORY"}, Syntax: "cmd.history.syntax", Description: "cmd.history.desc"}
+}
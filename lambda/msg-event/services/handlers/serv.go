@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
 	"strings"
@@ -11,6 +14,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const sevKey = "响应速度"
+
 type serv struct {
 }
 
@@ -18,6 +23,8 @@ func GetServ() api.Server {
 	return &serv{}
 }
 
+// Handle sets the severity, only the severities the support plan of the
+// account allows are accepted.
 func (s *serv) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
 
 	c, err = dao.GetCaseByEvent(e)
@@ -25,23 +32,99 @@ func (s *serv) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
 		logrus.Errorf("failed to get case %s", err)
 		return nil, err
 	}
-	service := strings.Trim(str, " ")
-	_, ok := config.SevMap[service]
-	if !ok {
-		c.SevCode = "normal"
-	} else {
-		c.SevCode = service
+	levels, err := caseSeverities(c)
+	if err != nil {
+		return nil, err
 	}
+	sev := strings.Trim(str, " ")
+	if !hasSeverity(levels, sev) {
+		return nil, i18n.Errorf("severity.unsupported", sev)
+	}
+	c.SevCode = sev
 
 	c.UpdateTime = time.Now().String()
-	for i, element := range c.CardMsg.Card.Elements {
-		if element.Extra.Value.Key == e.Action.Value.Key {
-			c.CardMsg.Card.Elements[i].Extra.InitialOption = e.Action.Option
-		}
+	if i := cardElement(&c.CardMsg.Card, sevKey); i >= 0 {
+		c.CardMsg.Card.Elements[i].Extra.InitialOption = sev
 	}
-	return dao.UpsertCase(c)
+	if e.Action != nil {
+		return dao.UpsertCase(c)
+	}
+	return replyCard(c)
 }
 
 func (s *serv) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *serv) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *serv) Describe() api.Command {
+	return api.Command{Name: sevKey, Aliases: []string{"SEVERITY"}, Syntax: "cmd.severity.syntax", Description: "cmd.severity.desc"}
+}
+
+func caseSeverities(c *dao.Case) ([]dao.SeverityLevel, error) {
+	key, err := caseAccount(c)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := dao.GetSeverityLevels(key, c.GetLanguage())
+	if err != nil {
+		if errors.Is(err, dao.ErrNoSupportPlan) {
+			return nil, accountErr(err)
+		}
+		return nil, i18n.Errorf("severity.failed", err)
+	}
+	return levels, nil
+}
+
+// refreshSeverities lists the severities on the card, a chosen severity the
+// account does not allow is cleared.
+func refreshSeverities(c *dao.Case, levels []dao.SeverityLevel) {
+	i := cardElement(&c.CardMsg.Card, sevKey)
+	if i < 0 {
+		return
+	}
+	extra := &c.CardMsg.Card.Elements[i].Extra
+	extra.Options = severityOptions(dao.CaseLocale(c), levels)
+	if !hasSeverity(levels, c.SevCode) {
+		c.SevCode = ""
+		extra.InitialOption = ""
+	}
+}
+
+// severityOptions lists the severities for a selector, the labels of the
+// card template of the locale are kept for the severities it has.
+func severityOptions(l i18n.Locale, levels []dao.SeverityLevel) []model.Options {
+	labels := map[string]string{}
+	if t := dao.CaseCardTemplate(l); t != nil {
+		if j := cardElement(&t.Card, sevKey); j >= 0 {
+			for _, o := range t.Card.Elements[j].Extra.Options {
+				labels[o.Value] = o.Text.Content
+			}
+		}
+	}
+
+	opts := make([]model.Options, 0, len(levels))
+	for _, level := range levels {
+		label, ok := labels[level.Code]
+		if !ok {
+			label = level.Name
+		}
+		opts = append(opts, model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: label},
+			Value: level.Code,
+		})
+	}
+	return opts
+}
+
+func hasSeverity(levels []dao.SeverityLevel, code string) bool {
+	for _, l := range levels {
+		if l.Code == code {
+			return true
+		}
+	}
+	return false
+}
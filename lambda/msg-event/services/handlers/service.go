@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
 	"strings"
@@ -11,35 +14,307 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	serviceKey  = "服务"
+	categoryKey = "类别"
+	// maxServiceOptions keeps the case card below the size limit of Lark
+	// cards, the other services are found with "服务 关键字"
+	maxServiceOptions = 100
+)
+
 type serviceServ struct {
 }
 
+type categoryServ struct {
+}
+
 func GetServiceServ() api.Server {
 	return &serviceServ{}
 }
 
+func GetCategoryServ() api.Server {
+	return &categoryServ{}
+}
+
+// Handle sets the service chosen on the card. As a text command it searches
+// the catalog, "服务 eks" narrows the selector to the matching services and
+// picks the service when it is the only or the exact match.
 func (s *serviceServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
 	c, err = dao.GetCaseByEvent(e)
 	if err != nil {
 		logrus.Errorf("failed to get case %s", err)
 		return nil, err
 	}
-	service := strings.Trim(str, " ")
-	_, ok := config.ServiceMap[service]
+	catalog, err := caseCatalog(c)
+	if err != nil {
+		return nil, err
+	}
+	if issueType(c) == dao.ISSUE_LIMIT {
+		return nil, i18n.Errorf("service.limit_case")
+	}
+	services := &dao.ServiceCatalog{Services: issueServices(c, catalog)}
+	keyword := strings.Trim(str, " ")
+
+	if e.Action != nil {
+		service, ok := services.Service(keyword)
+		if !ok {
+			return nil, i18n.Errorf("service.unknown", keyword)
+		}
+		selectService(c, service)
+		c.UpdateTime = time.Now().String()
+		return dao.UpsertCase(c)
+	}
+
+	matches := services.Services
+	if keyword != "" {
+		matches = services.Search(keyword)
+	}
+	if len(matches) == 0 {
+		return nil, i18n.Errorf("service.no_match", keyword)
+	}
+	setServiceOptions(c, catalog, matches)
+	if len(matches) == 1 || strings.EqualFold(matches[0].Code, keyword) {
+		selectService(c, &matches[0])
+	}
+	c.UpdateTime = time.Now().String()
+
+	return replyCard(c)
+}
+
+func (s *serviceServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *serviceServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *serviceServ) Describe() api.Command {
+	return api.Command{Name: serviceKey, Aliases: []string{"SERVICE"}, Syntax: "cmd.service.syntax", Description: "cmd.service.desc"}
+}
+
+// Handle sets the category of the chosen service, by code from the card or
+// by code or name as a text command.
+func (s *categoryServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	c, err = dao.GetCaseByEvent(e)
+	if err != nil {
+		logrus.Errorf("failed to get case %s", err)
+		return nil, err
+	}
+	if c.ServiceCode == "" {
+		return nil, i18n.Errorf("service.choose_first")
+	}
+	catalog, err := caseCatalog(c)
+	if err != nil {
+		return nil, err
+	}
+	service, ok := catalog.Service(c.ServiceCode)
 	if !ok {
-		c.ServiceCode = "1"
-	} else {
-		c.ServiceCode = service
+		return nil, i18n.Errorf("service.unknown", c.ServiceCode)
 	}
+	category, ok := service.Category(strings.Trim(str, " "))
+	if !ok {
+		return nil, i18n.Errorf("category.unknown", service.Name, strings.Trim(str, " "))
+	}
+
+	c.CategoryCode = category.Code
 	c.UpdateTime = time.Now().String()
-	for i, element := range c.CardMsg.Card.Elements {
-		if element.Extra.Value.Key == e.Action.Value.Key {
-			c.CardMsg.Card.Elements[i].Extra.InitialOption = e.Action.Option
+	if i := cardElement(&c.CardMsg.Card, categoryKey); i >= 0 {
+		c.CardMsg.Card.Elements[i].Extra.InitialOption = category.Code
+	}
+	if e.Action != nil {
+		return dao.UpsertCase(c)
+	}
+
+	return replyCard(c)
+}
+
+func (s *categoryServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *categoryServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *categoryServ) Describe() api.Command {
+	return api.Command{Name: categoryKey, Aliases: []string{"CATEGORY"}, Syntax: "cmd.category.syntax", Description: "cmd.category.desc"}
+}
+
+// caseAccount returns the account of the case. Nothing is loaded for an
+// account the user did not choose, an account without support plan must not
+// keep the user from the others.
+func caseAccount(c *dao.Case) (string, error) {
+	if c.AccountKey == "" {
+		return "", errChooseAccount
+	}
+	return c.AccountKey, nil
+}
+
+func caseCatalog(c *dao.Case) (*dao.ServiceCatalog, error) {
+	key, err := caseAccount(c)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := dao.GetServiceCatalog(key, c.GetLanguage())
+	if err != nil {
+		logrus.Errorf("failed to get service catalog of account %s, %v", key, err)
+		if errors.Is(err, dao.ErrNoSupportPlan) {
+			return nil, accountErr(err)
+		}
+		return nil, i18n.Errorf("service.catalog_failed", err)
+	}
+	return catalog, nil
+}
+
+// refreshServices lists the services of the catalog the issue type may use
+// on the card. The catalog differs between accounts, a chosen service or
+// category the catalog does not have is cleared.
+func refreshServices(c *dao.Case, catalog *dao.ServiceCatalog) error {
+	if issueType(c) == dao.ISSUE_LIMIT {
+		category := c.CategoryCode
+		if err := applyIssueType(c, catalog); err != nil {
+			return err
+		}
+		if limit, ok := catalog.Service(limitServiceCode); ok {
+			if _, ok := limit.Category(category); ok && category != "" {
+				c.CategoryCode = category
+				c.CardMsg.Card.Elements[cardElement(&c.CardMsg.Card, categoryKey)].Extra.InitialOption = category
+			}
 		}
+		return nil
 	}
+
+	services := issueServices(c, catalog)
+	if service, ok := findService(services, c.ServiceCode); ok {
+		category := c.CategoryCode
+		selectService(c, service)
+		if cat, ok := service.Category(category); ok && category != "" {
+			c.CategoryCode = cat.Code
+			c.CardMsg.Card.Elements[cardElement(&c.CardMsg.Card, categoryKey)].Extra.InitialOption = cat.Code
+		}
+	} else if c.ServiceCode != "" {
+		c.ServiceCode = ""
+		c.CategoryCode = ""
+		if i := cardElement(&c.CardMsg.Card, serviceKey); i >= 0 {
+			c.CardMsg.Card.Elements[i].Extra.InitialOption = ""
+		}
+		removeCardElement(&c.CardMsg.Card, categoryKey)
+	}
+	setServiceOptions(c, catalog, services)
+	return nil
+}
+
+func findService(services []dao.CatalogService, code string) (*dao.CatalogService, bool) {
+	for i := range services {
+		if services[i].Code == code {
+			return &services[i], true
+		}
+	}
+	return nil, false
+}
+
+// setServiceOptions lists at most maxServiceOptions of the services on the
+// service selector, the chosen service stays listed first.
+func setServiceOptions(c *dao.Case, catalog *dao.ServiceCatalog, services []dao.CatalogService) {
+	i := cardElement(&c.CardMsg.Card, serviceKey)
+	if i < 0 {
+		return
+	}
+	option := func(s *dao.CatalogService) model.Options {
+		return model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: s.Name},
+			Value: s.Code,
+		}
+	}
+
+	opts := make([]model.Options, 0, min(len(services), maxServiceOptions)+1)
+	if chosen, ok := catalog.Service(c.ServiceCode); ok {
+		opts = append(opts, option(chosen))
+	}
+	for j := 0; j < len(services) && len(opts) < maxServiceOptions; j++ {
+		if services[j].Code != c.ServiceCode {
+			opts = append(opts, option(&services[j]))
+		}
+	}
+	c.CardMsg.Card.Elements[i].Extra.Options = opts
+}
+
+// selectService chooses the service on the card and offers its categories,
+// a service with a single category gets it chosen as well.
+func selectService(c *dao.Case, service *dao.CatalogService) {
+	c.ServiceCode = service.Code
+	c.CategoryCode = ""
+	card := &c.CardMsg.Card
+	// limit increase cases have no service selector, their categories go
+	// below the issue type
+	anchor := issueTypeKey
+	if i := cardElement(card, serviceKey); i >= 0 {
+		card.Elements[i].Extra.InitialOption = service.Code
+		anchor = serviceKey
+	}
+
+	opts := make([]model.Options, 0, len(service.Categories))
+	for _, category := range service.Categories {
+		opts = append(opts, model.Options{
+			Text:  model.Text{Tag: "plain_text", Content: category.Name},
+			Value: category.Code,
+		})
+	}
+	if len(service.Categories) == 1 {
+		c.CategoryCode = service.Categories[0].Code
+	}
+
+	if cardElement(card, categoryKey) < 0 {
+		insertCardElement(card, anchor, categoryElement(dao.CaseLocale(c)))
+	}
+	j := cardElement(card, categoryKey)
+	card.Elements[j].Extra.Options = opts
+	card.Elements[j].Extra.InitialOption = c.CategoryCode
+}
+
+func categoryElement(l i18n.Locale) model.Elements {
+	return model.Elements{
+		Tag:  "div",
+		Text: model.Text{Tag: "lark_md", Content: i18n.T(l, "card.category")},
+		Extra: model.Extra{
+			Tag:         "select_static",
+			Placeholder: model.Placeholder{Tag: "plain_text", Content: i18n.T(l, "card.category.placeholder")},
+			Value:       model.Value{Key: categoryKey},
+		},
+	}
+}
+
+// replyCard sends the case card again after a text command and saves the
+// case.
+func replyCard(c *dao.Case) (*dao.Case, error) {
+	rsp, err := dao.SendCardMsg(c.CardMsg, c)
+	if err != nil {
+		logrus.Errorf("send card msg failed, %v", err)
+		return nil, err
+	}
+	c.CardRespMsgID = *rsp.Data.MessageId
 	return dao.UpsertCase(c)
 }
 
-func (s *serviceServ) ShouldHandle(e *event.Msg) bool {
-	return true
+// locale is the locale of the replies to the operator of the event.
+func locale(e *event.Msg) i18n.Locale {
+	return dao.GetLocale(e.ChatID(), e.Operator())
+}
+
+// cardElement returns the index of the element with the value key, -1 if
+// the card does not have it.
+func cardElement(card *model.Card, key string) int {
+	for i, element := range card.Elements {
+		if element.Extra.Value.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeCardElement(card *model.Card, key string) {
+	if i := cardElement(card, key); i >= 0 {
+		card.Elements = append(card.Elements[:i], card.Elements[i+1:]...)
+	}
 }
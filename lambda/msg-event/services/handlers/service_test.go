@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"msg-event/dao"
+	"msg-event/model"
+	"testing"
+)
+
+func TestRefreshServicesOnAccountChange(t *testing.T) {
+	ec2 := dao.CatalogService{Code: "amazon-ec2", Name: "EC2", Categories: []dao.CatalogCategory{
+		{Code: "instance-issue", Name: "Instance issue"}, {Code: "other", Name: "Other"}}}
+	ec2Fewer := dao.CatalogService{Code: "amazon-ec2", Name: "EC2", Categories: []dao.CatalogCategory{
+		{Code: "other", Name: "Other"}, {Code: "general-guidance", Name: "General guidance"}}}
+	s3 := dao.CatalogService{Code: "amazon-s3", Name: "S3", Categories: []dao.CatalogCategory{{Code: "other", Name: "Other"}}}
+
+	tests := []struct {
+		name         string
+		catalog      []dao.CatalogService
+		service      string
+		category     string
+		wantService  string
+		wantCategory string
+		wantOptions  int
+	}{
+		{name: "service and category kept", catalog: []dao.CatalogService{ec2, s3}, service: "amazon-ec2", category: "instance-issue",
+			wantService: "amazon-ec2", wantCategory: "instance-issue", wantOptions: 2},
+		{name: "category missing in the new account", catalog: []dao.CatalogService{ec2Fewer, s3}, service: "amazon-ec2", category: "instance-issue",
+			wantService: "amazon-ec2", wantOptions: 2},
+		{name: "service missing in the new account", catalog: []dao.CatalogService{s3}, service: "amazon-ec2", category: "instance-issue"},
+		{name: "single category is chosen", catalog: []dao.CatalogService{ec2, s3}, service: "amazon-s3",
+			wantService: "amazon-s3", wantCategory: "other", wantOptions: 1},
+		{name: "nothing chosen yet", catalog: []dao.CatalogService{ec2, s3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &dao.Case{Locale: "zh-CN", ServiceCode: tt.service, CategoryCode: tt.category, CardMsg: &model.FeiShuMsg{}}
+			card := &c.CardMsg.Card
+			card.Elements = append(card.Elements, model.Elements{Tag: "div",
+				Extra: model.Extra{Tag: "select_static", Value: model.Value{Key: serviceKey}, InitialOption: tt.service}})
+			if tt.service != "" {
+				card.Elements = append(card.Elements, categoryElement("zh-CN"))
+				card.Elements[1].Extra.InitialOption = tt.category
+			}
+
+			if err := refreshServices(c, &dao.ServiceCatalog{Services: tt.catalog}); err != nil {
+				t.Fatal(err)
+			}
+			if c.ServiceCode != tt.wantService || c.CategoryCode != tt.wantCategory {
+				t.Errorf("case %q %q, want %q %q", c.ServiceCode, c.CategoryCode, tt.wantService, tt.wantCategory)
+			}
+			if got := card.Elements[cardElement(card, serviceKey)].Extra.InitialOption; got != tt.wantService {
+				t.Errorf("service selector shows %q, want %q", got, tt.wantService)
+			}
+			i := cardElement(card, categoryKey)
+			if tt.wantService == "" {
+				if i >= 0 {
+					t.Errorf("category selector kept without a service")
+				}
+				return
+			}
+			if i < 0 {
+				t.Fatal("no category selector")
+			}
+			extra := card.Elements[i].Extra
+			if extra.InitialOption != tt.wantCategory || len(extra.Options) != tt.wantOptions {
+				t.Errorf("category selector shows %q of %d, want %q of %d", extra.InitialOption, len(extra.Options), tt.wantCategory, tt.wantOptions)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CreateChatOrNewCase opens the AWS case and its chat once the draft is
+// complete, drafts above the approval severity of the account wait for an
+// approver first.
+func CreateChatOrNewCase(caze *dao.Case) error {
+	caze.Print()
+	// a draft filled from a template waits for the confirm button
+	if caze.Confirming {
+		return nil
+	}
+
+	if strings.Trim(caze.Title, " ") != "" &&
+		hasCaseBody(caze) &&
+		strings.Trim(caze.SevCode, " ") != "" &&
+		strings.Trim(caze.ServiceCode, " ") != "" &&
+		strings.Trim(caze.CategoryCode, " ") != "" &&
+		strings.Trim(caze.AccountKey, " ") != "" &&
+		(caze.Status == dao.STATUS_NEW || caze.Status == dao.STATUS_PENDING_APPROVAL) {
+
+		if err := dao.CheckAccountAccess(caze.UserID, caze.AccountKey); err != nil {
+			logrus.Warnf("user %s can not open case with account %s, %v", caze.UserID, caze.AccountKey, err)
+			if errors.Is(err, dao.ErrAccountDenied) {
+				return i18n.Errorf("permission.denied")
+			}
+			return err
+		}
+
+		if needsApproval(caze) {
+			return requestApproval(caze)
+		}
+
+		caze.Status = dao.STATUS_OPEN
+		if issueType(caze) == dao.ISSUE_LIMIT {
+			caze.Content = limitBody(caze)
+		}
+		// only the status card sent to the case group gets the resolve button
+		card := &caze.CardMsg.Card
+		card.Elements = append(card.Elements, resolveElement(dao.CaseLocale(caze)))
+		caze, err := dao.CreateCaseAndChannel(caze)
+		removeCardElement(card, resolveKey)
+		if err != nil {
+			logrus.Errorf("failed to create case info %s", err)
+			return accountErr(err)
+		}
+		//clean up fromchannel
+		caze.ChannelID = caze.FromChannelID
+		caze.UserID = ""
+		caze.Title = ""
+		caze.Content = ""
+		caze.Type = dao.TYPE_OPEN_CASE
+		caze.SevCode = ""
+		caze.IssueType = ""
+		caze.ServiceCode = ""
+		caze.CategoryCode = ""
+		caze.Limit = nil
+		caze.CcEmails = nil
+		caze.Language = ""
+		caze.ApprovalStatus = ""
+		caze.ApprovalVersion = ""
+		caze.ApprovalMsgIDs = nil
+		caze.Approver = ""
+		caze.ApprovalTime = ""
+		caze.AWSStatus = ""
+		caze.StatusHistory = nil
+		caze.CardMsg.ChatId = caze.ChannelID
+		caze.CardMsg.UserId = caze.UserID
+		_, err = dao.UpsertCase(caze)
+		if err != nil {
+			logrus.Errorf("failed to cleanup root case info %s", err)
+			return err
+		}
+		return nil
+	}
+	return i18n.Errorf("case.incomplete")
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"msg-event/config"
 	"msg-event/dao"
 	"msg-event/model/event"
 	"msg-event/services/api"
@@ -47,3 +48,11 @@ func (s *titleServ) Handle(e *event.Msg, title string) (c *dao.Case, err error)
 func (s *titleServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
+
+func (s *titleServ) RequiredRole() config.Role {
+	return config.RoleSubmitter
+}
+
+func (s *titleServ) Describe() api.Command {
+	return api.Command{Name: "问题", Aliases: []string{"TITLE"}, Syntax: "cmd.title.syntax", Description: "cmd.title.desc"}
+}
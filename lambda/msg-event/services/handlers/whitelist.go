@@ -1,13 +1,14 @@
 package handlers
 
 import (
-	"errors"
-	"fmt"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -42,50 +43,27 @@ func GetAdminWhitelist() api.Server {
 }
 
 func (s *WhitlistServ) Handle(e *event.Msg, whitelist string) (c *dao.Case, err error) {
-	if _, ok := config.Conf.RoleMap[e.Event.Sender.SenderIDs.UserID]; !ok {
-		err = errors.New("你没有权限添加白名单")
-		return nil, err
-	}
-
-	whitelistItem := strings.Split(whitelist, ",")
-	var emailList []string
-	var phoneList []string
-
-	for _, item := range whitelistItem {
+	users, departments, chats := splitWhitelist(whitelist)
 
-		rtn := isEmail(item)
-		if rtn == true {
-			emailList = append(emailList, item)
-		} else {
-			phoneList = append(phoneList, item)
+	if len(users) > 0 {
+		validUer, err := lookupUsers(strings.Join(users, ","))
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	// //get userid by whitelist Email
-	var badUserList []string
-	var msg string
-	validUer, badUserList, err := dao.GetUserIdbyEmailOrPhone(emailList, phoneList)
-	if err != nil {
-		logrus.Errorf("Failed to send msg for whitelist, %v", err)
-		return nil, err
+		// //write userID to ddb
+		if err = dao.AddWhitelist(validUer); err != nil {
+			return nil, i18n.Errorf("whitelist.add_failed")
+		}
 	}
-
-	if len(badUserList) > 0 {
-		msg = fmt.Sprintf("无法获取 %v 对应的用户id，请核对是否是正确的电话号码或者邮箱", badUserList)
-		err = errors.New(msg)
-		return nil, err
+	if err = dao.AddDepartmentWhitelist(groupNames(departments, dao.GetDepartmentName)); err != nil {
+		return nil, i18n.Errorf("whitelist.add_department_failed")
 	}
-
-	// //write userID to ddb
-	err = dao.AddWhitelist(validUer)
-	if err != nil {
-		msg = "添加白名单失败，请重试"
-		err = errors.New(msg)
-		return nil, err
+	if err = dao.AddChatWhitelist(groupNames(chats, dao.GetChatName)); err != nil {
+		return nil, i18n.Errorf("whitelist.add_chat_failed")
 	}
-	msg = "添加白名单成功"
 	fromChannelID := e.Event.Message.ChatID
-	_, err = dao.SendMsgToChannel(fromChannelID, msg)
+	_, err = dao.SendMsgToChannel(fromChannelID, i18n.T(locale(e), "whitelist.added"))
 	return nil, nil
 }
 
@@ -93,6 +71,14 @@ func (s *WhitlistServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
 
+func (s *WhitlistServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *WhitlistServ) Describe() api.Command {
+	return api.Command{Name: "添加白名单", Aliases: []string{"WHITELIST_ADD"}, Syntax: "cmd.whitelist_add.syntax", Description: "cmd.whitelist_add.desc"}
+}
+
 func isEmail(s string) bool {
 	// 这是一个简单的邮箱正则，根据需要可以进一步完善
 	re := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
@@ -103,20 +89,85 @@ func (s *WhitelistDelServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
 
+func (s *WhitelistDelServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *WhitelistDelServ) Describe() api.Command {
+	return api.Command{Name: "删除白名单", Aliases: []string{"WHITELIST_DEL"}, Syntax: "cmd.whitelist_del.syntax", Description: "cmd.whitelist_del.desc"}
+}
+
 func (s *WhitelistCatServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
 
+func (s *WhitelistCatServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *WhitelistCatServ) Describe() api.Command {
+	return api.Command{Name: "查看白名单", Aliases: []string{"WHITELIST"}, Syntax: "cmd.whitelist.syntax", Description: "cmd.whitelist.desc"}
+}
+
 func (s *AdminWhitelistServ) ShouldHandle(e *event.Msg) bool {
 	return true
 }
 
+func (s *AdminWhitelistServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *AdminWhitelistServ) Describe() api.Command {
+	return api.Command{Name: "设置管理员", Aliases: []string{"SET_ADMIN"}, Syntax: "cmd.set_admin.syntax", Description: "cmd.set_admin.desc"}
+}
+
 func (s *WhitelistDelServ) Handle(e *event.Msg, whitelist string) (c *dao.Case, err error) {
-	if _, ok := config.Conf.RoleMap[e.Event.Sender.SenderIDs.UserID]; !ok {
-		err = errors.New("你没有权限删除白名单")
-		return nil, err
+	users, departments, chats := splitWhitelist(whitelist)
+
+	if len(users) > 0 {
+		validUer, err := lookupUsers(strings.Join(users, ","))
+		if err != nil {
+			return nil, err
+		}
+
+		if err = dao.DelWhiteList(validUer); err != nil {
+			return nil, i18n.Errorf("whitelist.del_failed")
+		}
+	}
+	if err = dao.DelDepartmentWhitelist(groupIDs(departments)); err != nil {
+		return nil, i18n.Errorf("whitelist.del_department_failed")
+	}
+	if err = dao.DelChatWhitelist(groupIDs(chats)); err != nil {
+		return nil, i18n.Errorf("whitelist.del_chat_failed")
+	}
+	fromChannelID := e.Event.Message.ChatID
+	_, err = dao.SendMsgToChannel(fromChannelID, i18n.T(locale(e), "whitelist.deleted"))
+	return nil, nil
+}
+
+// Handle sends the whitelist card, "查看白名单 2" opens the second page. The
+// buttons of the card come back here and update the card in place.
+func (s *WhitelistCatServ) Handle(e *event.Msg, whitelist string) (c *dao.Case, err error) {
+	if e.Action != nil && e.Action.Value != nil && e.Action.Value.Card == whitelistCardName {
+		v := e.Action.Value
+		page, _ := strconv.Atoi(v.Page)
+		notice, err := whitelistRowAction(locale(e), e.Operator(), v.Op, v.Target)
+		if err != nil {
+			return nil, err
+		}
+		return nil, dao.UpdateCardMsg(e.OpenMsgID, whitelistCard(locale(e), page, notice))
 	}
 
+	page, _ := strconv.Atoi(strings.Trim(whitelist, " "))
+	_, err = dao.SendCardMsg(&model.FeiShuMsg{
+		ChatId: e.Event.Message.ChatID,
+		Card:   *whitelistCard(locale(e), page, ""),
+	}, nil)
+	return nil, err
+}
+
+func (s *AdminWhitelistServ) Handle(e *event.Msg, whitelist string) (c *dao.Case, err error) {
+
 	whitelistItem := strings.Split(whitelist, ",")
 	var emailList []string
 	var phoneList []string
@@ -131,7 +182,6 @@ func (s *WhitelistDelServ) Handle(e *event.Msg, whitelist string) (c *dao.Case,
 	}
 
 	var badUserList []string
-	var msg string
 	validUer, badUserList, err := dao.GetUserIdbyEmailOrPhone(emailList, phoneList)
 	if err != nil {
 		logrus.Errorf("Failed to send msg for whitelist, %v", err)
@@ -139,81 +189,117 @@ func (s *WhitelistDelServ) Handle(e *event.Msg, whitelist string) (c *dao.Case,
 	}
 
 	if len(badUserList) > 0 {
-		msg = fmt.Sprintf("无法获取 %v 对应的用户id，请核对是否是正确的电话号码或者邮箱", badUserList)
-		err = errors.New(msg)
-		return nil, err
+		return nil, i18n.Errorf("whitelist.unknown_users", badUserList)
 	}
-
-	err = dao.DelWhiteList(validUer)
+	err = dao.SetAdmin(validUer)
 	if err != nil {
-		msg = "删除白名单失败，请重试"
-		err = errors.New(msg)
-		return nil, err
+		return nil, i18n.Errorf("whitelist.admin_failed")
 	}
-	msg = "删除白名单成功"
 	fromChannelID := e.Event.Message.ChatID
-	_, err = dao.SendMsgToChannel(fromChannelID, msg)
+	_, err = dao.SendMsgToChannel(fromChannelID, i18n.T(locale(e), "whitelist.admin_set"))
+
 	return nil, nil
 }
 
-func (s *WhitelistCatServ) Handle(e *event.Msg, whitelist string) (c *dao.Case, err error) {
-	if _, ok := config.Conf.RoleMap[e.Event.Sender.SenderIDs.UserID]; !ok {
-		err = errors.New("你没有权限查看白名单")
-		return nil, err
-	}
-	rtnWhitelist := dao.GetWhiteList()
-	msg := "白名单: "
-	for user, role := range rtnWhitelist {
-		msg += fmt.Sprintf("%s:%s ;", user, role)
-	}
-	logrus.Info(msg)
-	fromChannelID := e.Event.Message.ChatID
-	_, err = dao.SendMsgToChannel(fromChannelID, msg)
+type RoleServ struct {
+}
 
-	return nil, nil
+func GetRoleServ() api.Server {
+	return &RoleServ{}
 }
 
-func (s *AdminWhitelistServ) Handle(e *event.Msg, whitelist string) (c *dao.Case, err error) {
-	if _, ok := config.Conf.RoleMap[e.Event.Sender.SenderIDs.UserID]; !ok {
-		err = errors.New("你没有权限设置管理员")
+func (s *RoleServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *RoleServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *RoleServ) Describe() api.Command {
+	return api.Command{Name: "设置角色", Aliases: []string{"SET_ROLE"}, Syntax: "cmd.set_role.syntax", Description: "cmd.set_role.desc"}
+}
+
+// Handle sets the role of users, e.g. "设置角色 approver a@example.com,13800000000".
+func (s *RoleServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	tokens := strings.SplitN(strings.Trim(str, " "), " ", 2)
+	role := config.Role(strings.ToLower(tokens[0]))
+	if !role.Valid() || len(tokens) < 2 {
+		return nil, i18n.Errorf("role.usage")
+	}
+
+	validUser, err := lookupUsers(tokens[1])
+	if err != nil {
 		return nil, err
 	}
+	if err = dao.SetRole(validUser, role); err != nil {
+		return nil, i18n.Errorf("role.set_failed")
+	}
+	_, err = dao.SendMsgToChannel(e.Event.Message.ChatID, i18n.T(locale(e), "role.set", dao.RoleName(locale(e), role)))
+	return nil, nil
+}
 
-	whitelistItem := strings.Split(whitelist, ",")
+// lookupUsers resolves comma separated emails and phones to user ids.
+func lookupUsers(list string) (map[string]string, error) {
 	var emailList []string
 	var phoneList []string
-
-	for _, item := range whitelistItem {
-		rtn := isEmail(item)
-		if rtn == true {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.Trim(item, " ")
+		if isEmail(item) {
 			emailList = append(emailList, item)
 		} else {
 			phoneList = append(phoneList, item)
 		}
 	}
 
-	var badUserList []string
-	var msg string
-	validUer, badUserList, err := dao.GetUserIdbyEmailOrPhone(emailList, phoneList)
+	validUser, badUserList, err := dao.GetUserIdbyEmailOrPhone(emailList, phoneList)
 	if err != nil {
-		logrus.Errorf("Failed to send msg for whitelist, %v", err)
+		logrus.Errorf("Failed to get user id, %v", err)
 		return nil, err
 	}
-
 	if len(badUserList) > 0 {
-		msg = fmt.Sprintf("无法获取 %v 对应的用户id，请核对是否是正确的电话号码或者邮箱", badUserList)
-		err = errors.New(msg)
-		return nil, err
+		return nil, i18n.Errorf("whitelist.unknown_users", badUserList)
 	}
-	err = dao.SetAdmin(validUer)
-	if err != nil {
-		msg = "添加admin失败，请重试"
-		err = errors.New(msg)
-		return nil, err
+	return validUser, nil
+}
+
+// splitWhitelist sorts comma separated whitelist items into emails or phones,
+// open_department_ids (od-) and chat ids (oc_).
+func splitWhitelist(list string) (users, departments, chats []string) {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.Trim(item, " ")
+		switch {
+		case item == "":
+		case strings.HasPrefix(item, "od-"):
+			departments = append(departments, item)
+		case strings.HasPrefix(item, "oc_"):
+			chats = append(chats, item)
+		default:
+			users = append(users, item)
+		}
 	}
-	msg = "添加admin成功"
-	fromChannelID := e.Event.Message.ChatID
-	_, err = dao.SendMsgToChannel(fromChannelID, msg)
+	return users, departments, chats
+}
 
-	return nil, nil
+// groupNames looks up the names of departments or chats, the id is kept as
+// name when the lookup fails.
+func groupNames(ids []string, name func(string) (string, error)) map[string]string {
+	names := map[string]string{}
+	for _, id := range ids {
+		n, err := name(id)
+		if err != nil {
+			logrus.Warnf("failed to get name of %s, %v", id, err)
+			n = id
+		}
+		names[id] = n
+	}
+	return names
+}
+
+func groupIDs(ids []string) map[string]string {
+	m := map[string]string{}
+	for _, id := range ids {
+		m[id] = id
+	}
+	return m
 }
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	whitelistCardName = "whitelist"
+	whitelistPageSize = 10
+	whitelistOpRemove = "remove"
+	whitelistOpAdmin  = "promote"
+	whitelistOpPage   = "page"
+)
+
+// whitelistCard renders one page of the whitelist with a remove and a promote
+// button per user in the locale, notice is shown on top after a row action.
+func whitelistCard(l i18n.Locale, page int, notice string) *model.Card {
+	entries := dao.GetWhiteList()
+	pages := (len(entries) + whitelistPageSize - 1) / whitelistPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page < 1 {
+		page = 1
+	}
+	if page > pages {
+		page = pages
+	}
+
+	card := &model.Card{
+		Config: model.Config{WideScreenMode: true, UpdateMulti: true},
+		Header: &model.Header{
+			Title:    model.Text{Tag: "plain_text", Content: i18n.T(l, "whitelist.title", len(entries))},
+			Template: "blue",
+		},
+	}
+	if notice != "" {
+		card.Elements = append(card.Elements, model.Elements{Tag: "markdown", Content: notice})
+	}
+	if page == 1 {
+		if groups := whitelistGroups(l); groups != "" {
+			card.Elements = append(card.Elements, model.Elements{Tag: "markdown", Content: groups})
+		}
+	}
+
+	start := (page - 1) * whitelistPageSize
+	end := start + whitelistPageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+	for _, entry := range entries[start:end] {
+		name := entry.Contact
+		if u, err := dao.GetUserInfo(entry.UserID); err == nil && u.Name != "" {
+			name = fmt.Sprintf("%s (%s)", u.Name, entry.Contact)
+		}
+		card.Elements = append(card.Elements, model.Elements{Tag: "hr"}, model.Elements{
+			Tag:     "markdown",
+			Content: fmt.Sprintf("**%s**  %s", name, dao.RoleName(l, entry.Role)),
+		})
+		buttons := []model.Button{
+			whitelistButton(i18n.T(l, "whitelist.remove"), "danger", whitelistOpRemove, entry.UserID, page),
+		}
+		if entry.Role != config.RoleAdmin {
+			buttons = append(buttons, whitelistButton(i18n.T(l, "whitelist.promote"), "default", whitelistOpAdmin, entry.UserID, page))
+		}
+		card.Elements = append(card.Elements, model.Elements{Tag: "action", Actions: buttons})
+	}
+
+	var nav []model.Button
+	if page > 1 {
+		nav = append(nav, whitelistButton(i18n.T(l, "whitelist.prev"), "default", whitelistOpPage, "", page-1))
+	}
+	if page < pages {
+		nav = append(nav, whitelistButton(i18n.T(l, "whitelist.next"), "default", whitelistOpPage, "", page+1))
+	}
+	card.Elements = append(card.Elements, model.Elements{Tag: "hr"}, model.Elements{
+		Tag:     "markdown",
+		Content: i18n.T(l, "whitelist.page", page, pages),
+	})
+	if len(nav) > 0 {
+		card.Elements = append(card.Elements, model.Elements{Tag: "action", Actions: nav})
+	}
+	return card
+}
+
+func whitelistButton(text, style, op, target string, page int) model.Button {
+	return model.Button{
+		Tag:  "button",
+		Text: model.Text{Tag: "plain_text", Content: text},
+		Type: style,
+		Value: map[string]string{
+			"key":    "查看白名单",
+			"card":   whitelistCardName,
+			"op":     op,
+			"target": target,
+			"page":   strconv.Itoa(page),
+		},
+	}
+}
+
+func whitelistGroups(l i18n.Locale) string {
+	conf := config.Get()
+	s := ""
+	for id, name := range conf.DepartmentWhiteList {
+		s += i18n.T(l, "whitelist.department", name, id) + "\n"
+	}
+	for id, name := range conf.ChatWhiteList {
+		s += i18n.T(l, "whitelist.chat", name, id) + "\n"
+	}
+	return s
+}
+
+// whitelistRowAction runs the button of a row and returns the notice for the
+// refreshed card in the locale.
+func whitelistRowAction(l i18n.Locale, operator, op, target string) (string, error) {
+	if op == whitelistOpPage {
+		return "", nil
+	}
+	contact, ok := config.Get().UserWhiteListMap[target]
+	if !ok {
+		return i18n.T(l, "whitelist.gone"), nil
+	}
+	users := map[string]string{target: contact}
+
+	var err error
+	var notice string
+	switch op {
+	case whitelistOpRemove:
+		if target == operator {
+			return i18n.T(l, "whitelist.remove_self"), nil
+		}
+		err = dao.DelWhiteList(users)
+		notice = i18n.T(l, "whitelist.removed", contact)
+	case whitelistOpAdmin:
+		err = dao.SetAdmin(users)
+		notice = i18n.T(l, "whitelist.promoted", contact)
+	default:
+		return "", errors.New("unknown whitelist action " + op)
+	}
+	if err != nil {
+		logrus.Errorf("whitelist action %s on %s failed %v", op, target, err)
+		return i18n.T(l, "whitelist.action_failed"), nil
+	}
+	// render the card from the updated whitelist
+	if err = dao.SetupConfig(); err != nil {
+		return "", err
+	}
+	return notice, nil
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WhitelistImportKey is the command a csv file uploaded outside a case chat
+// is handled by.
+const WhitelistImportKey = "导入白名单"
+
+var errNotCSV = i18n.Errorf("csv.not_csv")
+
+type WhitelistImportServ struct {
+}
+
+type WhitelistExportServ struct {
+}
+
+func GetWhitelistImport() api.Server {
+	return &WhitelistImportServ{}
+}
+
+func GetWhitelistExport() api.Server {
+	return &WhitelistExportServ{}
+}
+
+func (s *WhitelistImportServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *WhitelistImportServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *WhitelistImportServ) Describe() api.Command {
+	return api.Command{Name: WhitelistImportKey, Aliases: []string{"WHITELIST_IMPORT"}, Description: "cmd.whitelist_import.desc"}
+}
+
+func (s *WhitelistExportServ) ShouldHandle(e *event.Msg) bool {
+	return true
+}
+
+func (s *WhitelistExportServ) RequiredRole() config.Role {
+	return config.RoleAdmin
+}
+
+func (s *WhitelistExportServ) Describe() api.Command {
+	return api.Command{Name: "导出白名单", Aliases: []string{"WHITELIST_EXPORT"}, Description: "cmd.whitelist_export.desc"}
+}
+
+// importRow is a row of the uploaded csv, Result is nil until the row
+// failed.
+type importRow struct {
+	Line     int
+	Contact  string
+	Role     config.Role
+	Accounts []string
+	UserID   string
+	Result   error
+}
+
+// Handle imports the csv file of the message, every row is reported back as
+// a row of a result csv.
+func (s *WhitelistImportServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	content := &model.Content{}
+	if e.Event.Message.MsgType != "file" {
+		return nil, errNotCSV
+	}
+	if err = json.Unmarshal([]byte(e.Event.Message.Content), content); err != nil {
+		return nil, err
+	}
+	data, err := dao.DownloadFile(e.Event.Message.MsgID, content.FileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := parseWhitelistCSV(data)
+	if err != nil {
+		return nil, i18n.Errorf("csv.parse_failed", err)
+	}
+	if err = resolveImportRows(rows); err != nil {
+		return nil, err
+	}
+	applyImportRows(rows)
+
+	report := &bytes.Buffer{}
+	w := csv.NewWriter(report)
+	w.Write([]string{"row", "contact", "result"})
+	l := locale(e)
+	var succeeded, failed int
+	for _, r := range rows {
+		result := i18n.T(l, "csv.row_ok")
+		if r.Result == nil {
+			succeeded++
+		} else {
+			result = i18n.Message(l, r.Result)
+			failed++
+		}
+		w.Write([]string{strconv.Itoa(r.Line), r.Contact, result})
+	}
+	w.Flush()
+
+	chatID := e.ChatID()
+	if _, err = dao.SendMsgToChannel(chatID, i18n.T(l, "csv.imported", succeeded, failed)); err != nil {
+		return nil, err
+	}
+	return nil, dao.SendFile(chatID, "whitelist_import_result.csv", report.Bytes())
+}
+
+// Handle sends the whitelisted users as a csv file that can be imported again.
+func (s *WhitelistExportServ) Handle(e *event.Msg, str string) (c *dao.Case, err error) {
+	conf := config.Get()
+	var users map[string][]string
+	if conf.AccountPolicy != nil {
+		users = conf.AccountPolicy.Users
+	}
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write([]string{"contact", "role", "accounts"})
+	for _, entry := range dao.GetWhiteList() {
+		w.Write([]string{entry.Contact, string(entry.Role), strings.Join(users[entry.UserID], ";")})
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("whitelist_%s.csv", time.Now().Format("20060102"))
+	return nil, dao.SendFile(e.ChatID(), name, buf.Bytes())
+}
+
+// parseWhitelistCSV reads the rows of the csv, a header row is skipped. Rows
+// that are not valid get their Result set.
+func parseWhitelistCSV(data []byte) ([]*importRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var rows []*importRow
+	seen := map[string]bool{}
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		contact := strings.TrimSpace(record[0])
+		if first && isHeader(contact) {
+			continue
+		}
+		if contact == "" {
+			continue
+		}
+		// blank lines are skipped by the reader, the report names the line
+		// of the file
+		line, _ := r.FieldPos(0)
+		row := &importRow{Line: line, Contact: contact}
+		rows = append(rows, row)
+
+		key := strings.ToLower(contact)
+		if seen[key] {
+			row.Result = i18n.Errorf("csv.duplicate")
+			continue
+		}
+		seen[key] = true
+
+		if len(record) > 1 {
+			row.Role = parseRole(record[1])
+			if !row.Role.Valid() && row.Role != "" {
+				row.Result = i18n.Errorf("csv.unknown_role", strings.TrimSpace(record[1]))
+				continue
+			}
+		}
+		if len(record) > 2 {
+			row.Accounts, row.Result = parseAccounts(record[2])
+		}
+	}
+	return rows, nil
+}
+
+func isHeader(cell string) bool {
+	switch strings.ToLower(cell) {
+	case "contact", "email", "phone", "邮箱", "电话", "邮箱或电话":
+		return true
+	}
+	return false
+}
+
+// parseRole accepts the role or its name in any locale, case is ignored.
+func parseRole(s string) config.Role {
+	s = strings.TrimSpace(s)
+	for _, l := range i18n.Locales() {
+		for _, role := range config.Roles {
+			if strings.EqualFold(s, dao.RoleName(l, role)) {
+				return role
+			}
+		}
+	}
+	return config.Role(strings.ToLower(s))
+}
+
+// parseAccounts splits the accounts separated by ; or |, the second result
+// names an unknown account.
+func parseAccounts(s string) ([]string, error) {
+	var keys []string
+	for _, k := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '|' }) {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if _, ok := config.Get().Accounts[k]; !ok && k != config.AllAccounts {
+			return nil, i18n.Errorf("csv.unknown_account", k)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// resolveImportRows looks up the user ids of the valid rows.
+func resolveImportRows(rows []*importRow) error {
+	var contacts []string
+	for _, r := range rows {
+		if r.Result == nil {
+			contacts = append(contacts, r.Contact)
+		}
+	}
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	userIDs, err := dao.ResolveContacts(contacts)
+	if err != nil {
+		logrus.Errorf("Failed to resolve contacts, %v", err)
+		return err
+	}
+	for _, r := range rows {
+		if r.Result != nil {
+			continue
+		}
+		if r.UserID = userIDs[r.Contact]; r.UserID == "" {
+			r.Result = i18n.Errorf("csv.unknown_user")
+		}
+	}
+	return nil
+}
+
+// importBatch keeps the update expressions below the DynamoDB size limit.
+const importBatch = 50
+
+// applyImportRows writes the whitelist, the roles and the accounts of the
+// valid rows in batches. The config is reloaded after every write as the
+// next write depends on which maps exist.
+func applyImportRows(rows []*importRow) {
+	byRole := map[config.Role][]*importRow{}
+	for _, r := range rows {
+		if r.Result == nil {
+			byRole[r.Role] = append(byRole[r.Role], r)
+		}
+	}
+
+	for role, group := range byRole {
+		for len(group) > 0 {
+			batch := group[:min(len(group), importBatch)]
+			group = group[len(batch):]
+
+			users := map[string]string{}
+			for _, r := range batch {
+				users[r.UserID] = r.Contact
+			}
+			var err error
+			if role == "" {
+				err = dao.AddWhitelist(users)
+			} else {
+				err = dao.SetRole(users, role)
+			}
+			failImportRows(batch, err, i18n.Errorf("csv.add_failed"))
+		}
+	}
+
+	var withAccounts []*importRow
+	for _, r := range rows {
+		if r.Result == nil && len(r.Accounts) > 0 {
+			withAccounts = append(withAccounts, r)
+		}
+	}
+	for len(withAccounts) > 0 {
+		batch := withAccounts[:min(len(withAccounts), importBatch)]
+		withAccounts = withAccounts[len(batch):]
+
+		accounts := map[string][]string{}
+		for _, r := range batch {
+			accounts[r.UserID] = r.Accounts
+		}
+		failImportRows(batch, dao.SetUserAccounts(accounts), i18n.Errorf("csv.accounts_failed"))
+	}
+}
+
+// failImportRows marks the rows a write failed for and reloads the config.
+func failImportRows(rows []*importRow, err error, result error) {
+	if err != nil {
+		logrus.Errorf("%v, %v", result, err)
+		for _, r := range rows {
+			if r.Result == nil {
+				r.Result = result
+			}
+		}
+	}
+	if err = dao.SetupConfig(); err != nil {
+		logrus.Errorf("failed to reload config, %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"msg-event/config"
+	"msg-event/i18n"
+	"reflect"
+	"testing"
+)
+
+// useConfig sets the config for the test and restores the previous one.
+func useConfig(t *testing.T, c *config.Config) {
+	prev := config.Get()
+	config.Set(c)
+	t.Cleanup(func() { config.Set(prev) })
+}
+
+// errKey is the message key of an i18n error, or its text.
+func errKey(err error) string {
+	if err == nil {
+		return ""
+	}
+	var e *i18n.Error
+	if errors.As(err, &e) {
+		return e.Key
+	}
+	return err.Error()
+}
+
+func TestParseWhitelistCSV(t *testing.T) {
+	useConfig(t, &config.Config{Accounts: map[string]*config.Account{"prod": {}, "dev": {}}})
+
+	type row struct {
+		Line     int
+		Contact  string
+		Role     config.Role
+		Accounts []string
+		Result   string
+	}
+	tests := []struct {
+		name    string
+		data    string
+		want    []row
+		wantErr bool
+	}{
+		{
+			name: "header and byte order mark are skipped",
+			data: "\xef\xbb\xbfcontact,role,accounts\na@example.com,admin,prod\n",
+			want: []row{{Line: 2, Contact: "a@example.com", Role: config.RoleAdmin, Accounts: []string{"prod"}}},
+		},
+		{
+			name: "chinese header and role names",
+			data: "邮箱或电话,角色,账户\n13800000000,提交者,\nb@example.com,审批者\n",
+			want: []row{
+				{Line: 2, Contact: "13800000000", Role: config.RoleSubmitter},
+				{Line: 3, Contact: "b@example.com", Role: config.RoleApprover},
+			},
+		},
+		{
+			name: "english role names",
+			data: "a@example.com,Approver\nb@example.com,ADMIN,prod\nc@example.com,None\n",
+			want: []row{
+				{Line: 1, Contact: "a@example.com", Role: config.RoleApprover},
+				{Line: 2, Contact: "b@example.com", Role: config.RoleAdmin, Accounts: []string{"prod"}},
+				{Line: 3, Contact: "c@example.com"},
+			},
+		},
+		{
+			name: "contact only, role case and spaces",
+			data: "a@example.com\n b@example.com , Viewer \n",
+			want: []row{
+				{Line: 1, Contact: "a@example.com"},
+				{Line: 2, Contact: "b@example.com", Role: config.RoleViewer},
+			},
+		},
+		{
+			name: "accounts separated by semicolon or bar",
+			data: "a@example.com,submitter,prod; dev|*\n",
+			want: []row{{Line: 1, Contact: "a@example.com", Role: config.RoleSubmitter, Accounts: []string{"prod", "dev", "*"}}},
+		},
+		{
+			name: "empty contacts are skipped",
+			data: "a@example.com\n,admin\n\nc@example.com\n",
+			want: []row{{Line: 1, Contact: "a@example.com"}, {Line: 4, Contact: "c@example.com"}},
+		},
+		{
+			name: "duplicate contact ignores case",
+			data: "a@example.com,admin\nA@Example.com,viewer\n",
+			want: []row{
+				{Line: 1, Contact: "a@example.com", Role: config.RoleAdmin},
+				{Line: 2, Contact: "A@Example.com", Result: "csv.duplicate"},
+			},
+		},
+		{
+			name: "unknown role",
+			data: "a@example.com,owner,prod\n",
+			want: []row{{Line: 1, Contact: "a@example.com", Role: "owner", Result: "csv.unknown_role"}},
+		},
+		{
+			name: "unknown account",
+			data: "a@example.com,viewer,prod;staging\n",
+			want: []row{{Line: 1, Contact: "a@example.com", Role: config.RoleViewer, Result: "csv.unknown_account"}},
+		},
+		{
+			name:    "bare quote",
+			data:    "a@example.com,\"admin\n",
+			wantErr: true,
+		},
+		{
+			name:    "quote inside a field",
+			data:    "a\"b@example.com,admin\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseWhitelistCSV([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			var got []row
+			for _, r := range rows {
+				got = append(got, row{Line: r.Line, Contact: r.Contact, Role: r.Role, Accounts: r.Accounts, Result: errKey(r.Result)})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package processors
+
+import (
+	"errors"
+	"fmt"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model/event"
+	"msg-event/services/api"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+type accountSyncProcessor struct {
+}
+
+func GetAccountSyncProcessor() api.Processor {
+	return &accountSyncProcessor{}
+}
+
+func (r accountSyncProcessor) ShouldProcess(e *event.Msg) bool {
+	return true
+}
+
+func (r accountSyncProcessor) Process(e *event.Msg) error {
+	report, err := dao.SyncAccounts(context.Background())
+	if errors.Is(err, dao.ErrAccountSyncDisabled) {
+		logrus.Infof("skip account sync, %v", err)
+		return nil
+	}
+	if err != nil {
+		logrus.Errorf("account sync failed %v", err)
+		return err
+	}
+	logrus.Infof("account sync added %v, removed %v, unassumable %d, assumable again %v",
+		report.Added, report.Removed, len(report.Unassumable), report.Assumable)
+
+	chatID := config.Get().AccountSync.ReportChatID
+	if chatID == "" || len(report.Added)+len(report.Removed)+len(report.Unassumable)+len(report.Assumable) == 0 {
+		return nil
+	}
+	_, err = dao.SendMsgToChannel(chatID, formatSyncReport(dao.GetLocale(chatID, ""), report))
+	if err != nil {
+		logrus.Errorf("failed to send account sync report %v", err)
+	}
+	return err
+}
+
+func formatSyncReport(l i18n.Locale, report *dao.AccountSyncReport) string {
+	var b strings.Builder
+	b.WriteString(i18n.T(l, "sync.done") + "\\n")
+	if len(report.Added) > 0 {
+		b.WriteString(i18n.T(l, "sync.added", strings.Join(report.Added, ", ")) + "\\n")
+	}
+	if len(report.Removed) > 0 {
+		b.WriteString(i18n.T(l, "sync.removed", strings.Join(report.Removed, ", ")) + "\\n")
+	}
+	if len(report.Unassumable) > 0 {
+		keys := make([]string, 0, len(report.Unassumable))
+		for k := range report.Unassumable {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		// the text is put into the message json as is
+		escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+		b.WriteString(i18n.T(l, "sync.unassumable") + "\\n")
+		for _, k := range keys {
+			b.WriteString(fmt.Sprintf("%s: %s\\n", k, escape.Replace(report.Unassumable[k].Error())))
+		}
+	}
+	if len(report.Assumable) > 0 {
+		b.WriteString(i18n.T(l, "sync.assumable", strings.Join(report.Assumable, ", ")) + "\\n")
+	}
+	return b.String()
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
 	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
+	"msg-event/services/handlers"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -15,7 +18,7 @@ type attaProcessor struct {
 }
 
 func (r attaProcessor) ShouldProcess(e *event.Msg) bool {
-	return true
+	return permitted(e, config.RoleSubmitter)
 }
 
 func GetAttaProcessor() api.Processor {
@@ -23,13 +26,24 @@ func GetAttaProcessor() api.Processor {
 }
 
 func (r attaProcessor) Process(e *event.Msg) error {
+	content := &model.Content{}
+	if err := json.Unmarshal([]byte(e.Event.Message.Content), content); err != nil {
+		return err
+	}
 	c, err := dao.GetCaseByEvent(e)
-	if err != nil {
+	if strings.HasSuffix(strings.ToLower(content.FileName), ".csv") && (err != nil || c.Type != dao.TYPE_CASE) {
+		// a csv sent outside a case chat is a whitelist import
+		if _, err = dispatch(e, serverManager[handlers.WhitelistImportKey], ""); err != nil {
+			logrus.Errorf("failed to import whitelist %v", err)
+			dao.SendErrCardMsg(e.ChatID(), e.Operator(), err)
+		}
 		return err
 	}
 
-	content := &model.Content{}
-	if err = json.Unmarshal([]byte(e.Event.Message.Content), content); err != nil {
+	if !r.ShouldProcess(e) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 	data, err := dao.DownloadFile(e.Event.Message.MsgID, content.FileKey)
@@ -42,7 +56,7 @@ func (r attaProcessor) Process(e *event.Msg) error {
 		return err
 	}
 
-	dao.SendMsg(c.ChannelID, c.UserID, config.Conf.Ack)
+	dao.SendMsg(c.ChannelID, c.UserID, i18n.T(dao.CaseLocale(c), "comment.ack"))
 
 	return nil
 }
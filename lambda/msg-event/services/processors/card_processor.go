@@ -2,11 +2,8 @@ package processors
 
 import (
 	"errors"
-	"msg-event/config"
-	"msg-event/dao"
 	"msg-event/model/event"
 	"msg-event/services/api"
-	"os"
 
 	"github.com/sirupsen/logrus"
 )
@@ -18,16 +15,9 @@ func GetCardProcessor() api.Processor {
 	return &cardProcessor{}
 }
 
+// ShouldProcess accepts every card action, permissions are checked per
+// command by dispatch.
 func (r cardProcessor) ShouldProcess(e *event.Msg) bool {
-	//perimission judgetment
-	userId := e.UserID
-	_, ok := config.Conf.UserWhiteListMap[userId]
-
-	if os.Getenv("ENABLE_USER_WHITELIST") == "true" && !ok {
-		fromChannelID := e.Event.Message.ChatID
-		dao.SendMsgToChannel(fromChannelID, config.Conf.NoPermissionMSG)
-		return false
-	}
 	return true
 }
 
@@ -38,7 +28,7 @@ func (r cardProcessor) Process(e *event.Msg) (err error) {
 		}
 		if v, ok := serverManager[e.Action.Value.Key]; ok {
 			logrus.Infof("commond %s. value %s", e.Action.Value, e.Action.Option)
-			_, err = v.Handle(e, e.Action.Option)
+			_, err = dispatch(e, v, e.Action.Option)
 			if err != nil {
 				logrus.Errorf("faile to handle card msg %v", err)
 				return err
@@ -0,0 +1,172 @@
+package processors
+
+import (
+	"msg-event/i18n"
+	"msg-event/model/event"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// commandSpaces end the command. A colon ends a Chinese command as well,
+// e.g. "账户：prod", English text like "Q: ..." stays as it is. In a case
+// group "服务：已经恢复了" is a reply, see parseMessage.
+const (
+	commandSpaces = " \t\n"
+	commandColons = ":："
+)
+
+// quotePairs are the quotes a whole argument may be wrapped in.
+var quotePairs = [][2]string{{`"`, `"`}, {"'", "'"}, {"“", "”"}, {"‘", "’"}, {"「", "」"}}
+
+// parseCommand splits a text message into the command and its arguments,
+// full-width spaces count as spaces. The mentions the message starts with,
+// usually the bot in a group chat, are removed and the other mentions become
+// "@name". The arguments keep their line breaks, an argument wrapped in
+// quotes is unquoted. text is the whole message without the leading
+// mentions, for messages that are not commands. colon lets a colon end a
+// Chinese command.
+func parseCommand(raw string, mentions event.Mentions, colon bool) (cmd, args, text string) {
+	text = stripMentions(raw, mentions)
+	text = strings.TrimFunc(strings.ReplaceAll(text, "　", " "), unicode.IsSpace)
+	cmd = text
+	i := strings.IndexAny(text, commandSpaces)
+	if j := strings.IndexAny(text, commandColons); colon && j > 0 && (i < 0 || j < i) && !isASCII(text[:j]) {
+		i = j
+	}
+	if i >= 0 {
+		cmd, args = text[:i], text[i:]
+		// a single separator run ends the command, the line breaks of the
+		// arguments are kept
+		args = strings.TrimLeft(args, " \t"+commandColons)
+		args = strings.TrimPrefix(args, "\n")
+	}
+	return cmd, unquote(strings.TrimRightFunc(args, unicode.IsSpace)), text
+}
+
+// parseMessage parses the message like parseCommand, a colon only ends the
+// command outside case groups. inCase is asked only when the colon matters.
+func parseMessage(raw string, mentions event.Mentions, inCase func() bool) (cmd, args, text string) {
+	cmd, args, text = parseCommand(raw, mentions, true)
+	if plain, plainArgs, _ := parseCommand(raw, mentions, false); plain != cmd && inCase() {
+		return plain, plainArgs, text
+	}
+	return cmd, args, text
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func stripMentions(text string, mentions event.Mentions) string {
+	// longer keys first, "@_user_1" is a prefix of "@_user_10"
+	sorted := append(event.Mentions(nil), mentions...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i].Key) > len(sorted[j].Key) })
+	for {
+		trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+		found := false
+		for _, m := range sorted {
+			if m.Key != "" && strings.HasPrefix(trimmed, m.Key) {
+				text, found = trimmed[len(m.Key):], true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	for _, m := range sorted {
+		if m.Key != "" {
+			text = strings.ReplaceAll(text, m.Key, "@"+m.Name)
+		}
+	}
+	return text
+}
+
+func unquote(s string) string {
+	for _, q := range quotePairs {
+		if len(s) >= len(q[0])+len(q[1]) && strings.HasPrefix(s, q[0]) && strings.HasSuffix(s, q[1]) {
+			inner := s[len(q[0]) : len(s)-len(q[1])]
+			if !strings.Contains(inner, q[1]) {
+				return inner
+			}
+		}
+	}
+	return s
+}
+
+// lookupCommand finds the command by its exact name, English aliases are
+// upper case so that prose like "Service is down" stays a case reply.
+func lookupCommand(cmd string) (string, bool) {
+	if _, ok := serverManager[cmd]; ok && cmd != defaultKey {
+		return cmd, true
+	}
+	return "", false
+}
+
+// suggestCommand returns the commands the word is a likely typo of, it is
+// only asked outside case groups where every other message is a reply. Two
+// character commands only match a word of the same length with one character
+// changed, so that a short reply like "没问题" is not taken for "问题".
+func suggestCommand(word string) []string {
+	n := utf8.RuneCountInString(word)
+	if n == 0 || n > 12 {
+		return nil
+	}
+	var suggestions []string
+	for name := range serverManager {
+		if name == defaultKey {
+			continue
+		}
+		m := utf8.RuneCountInString(name)
+		limit := 1
+		if m > 4 {
+			limit = 2
+		}
+		if m < 2 || m == 2 && n != 2 {
+			continue
+		}
+		// "help" is offered HELP, aliases only match in upper case
+		if d := editDistance(strings.ToUpper(word), strings.ToUpper(name)); word != name && d <= limit {
+			suggestions = append(suggestions, name)
+		}
+	}
+	sort.Strings(suggestions)
+	return suggestions
+}
+
+func suggestionMsg(l i18n.Locale, word string, suggestions []string) string {
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = "“" + s + "”"
+	}
+	return i18n.T(l, "command.suggest", word, strings.Join(quoted, i18n.T(l, "list.or")))
+}
+
+// editDistance is the Levenshtein distance of the runes of a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
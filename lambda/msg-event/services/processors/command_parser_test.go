@@ -0,0 +1,141 @@
+package processors
+
+import (
+	"encoding/json"
+	"msg-event/model/event"
+	"reflect"
+	"testing"
+)
+
+func mentions(t *testing.T, keyNames ...string) event.Mentions {
+	var raw []map[string]string
+	for i := 0; i+1 < len(keyNames); i += 2 {
+		raw = append(raw, map[string]string{"key": keyNames[i], "name": keyNames[i+1]})
+	}
+	bs, _ := json.Marshal(raw)
+	var m event.Mentions
+	if err := json.Unmarshal(bs, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestParseCommand(t *testing.T) {
+	bot := mentions(t, "@_user_1", "Bot")
+	tests := []struct {
+		name     string
+		raw      string
+		mentions event.Mentions
+		noColon  bool
+		wantCmd  string
+		wantArgs string
+		wantText string
+	}{
+		{name: "command and argument", raw: "开工单 EC2 无法启动", wantCmd: "开工单", wantArgs: "EC2 无法启动", wantText: "开工单 EC2 无法启动"},
+		{name: "leading bot mention", raw: "@_user_1 内容 启动失败", mentions: bot, wantCmd: "内容", wantArgs: "启动失败", wantText: "内容 启动失败"},
+		{name: "several leading mentions", raw: "@_user_1 @_user_10 账户 prod",
+			mentions: mentions(t, "@_user_1", "Bot", "@_user_10", "Alice"), wantCmd: "账户", wantArgs: "prod", wantText: "账户 prod"},
+		{name: "mention inside the argument", raw: "@_user_1 内容 请 @_user_10 看看",
+			mentions: mentions(t, "@_user_1", "Bot", "@_user_10", "Alice"), wantCmd: "内容", wantArgs: "请 @Alice 看看", wantText: "内容 请 @Alice 看看"},
+		{name: "full-width space", raw: "内容　实例　启动失败", wantCmd: "内容", wantArgs: "实例 启动失败", wantText: "内容 实例 启动失败"},
+		{name: "full-width colon", raw: "账户：prod", wantCmd: "账户", wantArgs: "prod", wantText: "账户：prod"},
+		{name: "colon and space", raw: "服务: EC2", wantCmd: "服务", wantArgs: "EC2", wantText: "服务: EC2"},
+		{name: "colon not allowed", raw: "服务：已经恢复了", noColon: true, wantCmd: "服务：已经恢复了", wantText: "服务：已经恢复了"},
+		{name: "english colon stays in the word", raw: "Q: why", wantCmd: "Q:", wantArgs: "why", wantText: "Q: why"},
+		{name: "line break keeps the lines", raw: "内容\nline one\n\nline two\n", wantCmd: "内容", wantArgs: "line one\n\nline two",
+			wantText: "内容\nline one\n\nline two"},
+		{name: "double quotes", raw: `问题 "EC2 无法启动"`, wantCmd: "问题", wantArgs: "EC2 无法启动", wantText: `问题 "EC2 无法启动"`},
+		{name: "chinese quotes", raw: "问题 “EC2 无法启动”", wantCmd: "问题", wantArgs: "EC2 无法启动", wantText: "问题 “EC2 无法启动”"},
+		{name: "corner brackets", raw: "问题 「EC2」", wantCmd: "问题", wantArgs: "EC2", wantText: "问题 「EC2」"},
+		{name: "two quoted parts stay", raw: `内容 "a" and "b"`, wantCmd: "内容", wantArgs: `"a" and "b"`, wantText: `内容 "a" and "b"`},
+		{name: "unbalanced quote stays", raw: `问题 "EC2`, wantCmd: "问题", wantArgs: `"EC2`, wantText: `问题 "EC2`},
+		{name: "command only", raw: " 帮助 ", wantCmd: "帮助", wantText: "帮助"},
+		{name: "mention only", raw: "@_user_1", mentions: bot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, text := parseCommand(tt.raw, tt.mentions, !tt.noColon)
+			if cmd != tt.wantCmd || args != tt.wantArgs || text != tt.wantText {
+				t.Errorf("got %q %q %q, want %q %q %q", cmd, args, text, tt.wantCmd, tt.wantArgs, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	InitServices()
+	tests := []struct {
+		raw       string
+		caseGroup bool
+		wantName  string
+	}{
+		{raw: "开工单 EC2 无法启动", wantName: "开工单"},
+		{raw: "@_user_1 账户：prod", wantName: "账户"},
+		{raw: "服务：已经恢复了", wantName: "服务"},
+		{raw: "服务 EC2", caseGroup: true, wantName: "服务"},
+		{raw: "HELP", caseGroup: true, wantName: "HELP"},
+
+		// chinese replies with a colon in the case group are not commands
+		{raw: "服务：已经恢复了", caseGroup: true},
+		{raw: "内容：日志见附件", caseGroup: true},
+		{raw: "@_user_1 账户: 就是生产账户", caseGroup: true},
+		{raw: "HELP", wantName: "HELP"},
+		{raw: "RESOLVE", wantName: "RESOLVE"},
+		{raw: "SUBJECT EC2 does not start", wantName: "SUBJECT"},
+
+		// english replies to the case are not commands
+		{raw: "help"},
+		{raw: "Help me with the load balancer"},
+		{raw: "Service is down since 10am"},
+		{raw: "Resolved, thanks"},
+		{raw: "Resolve it please"},
+		{raw: "Confirmed"},
+		{raw: "Confirm the limit first"},
+		{raw: "For the record, it happened twice"},
+		{raw: "Form the cluster again"},
+		{raw: "Time to restart?"},
+		{raw: "Title of the ticket is wrong"},
+		{raw: "Region: us-east-1"},
+		{raw: "Account: 111111111111"},
+		{raw: "Severity: high"},
+		{raw: "Content: see the logs"},
+		{raw: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			cmd, _, _ := parseMessage(tt.raw, mentions(t, "@_user_1", "Bot"), func() bool { return tt.caseGroup })
+			name, ok := lookupCommand(cmd)
+			if name != tt.wantName || ok != (tt.wantName != "") {
+				t.Errorf("command %q %v, want %q", name, ok, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	InitServices()
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{word: "内荣", want: []string{"内容"}},
+		{word: "账号", want: []string{"账户"}},
+		{word: "开工丹", want: []string{"开工单"}},
+		{word: "HELPP", want: []string{"HELP"}},
+		{word: "help", want: []string{"HELP"}},
+		{word: "Resolve", want: []string{"RESOLVE"}},
+		{word: "HELP"},
+		{word: "内容"},
+		{word: "没问题"},
+		{word: "好的"},
+		{word: ""},
+		{word: "一个很长的句子不会被当作命令的拼写错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := suggestCommand(tt.word); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggested %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
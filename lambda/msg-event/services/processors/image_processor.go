@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"msg-event/config"
 	"msg-event/dao"
+	"msg-event/i18n"
 	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
@@ -16,7 +17,7 @@ type imageProcessor struct {
 }
 
 func (r imageProcessor) ShouldProcess(e *event.Msg) bool {
-	return true
+	return permitted(e, config.RoleSubmitter)
 }
 
 func GetImageProcessor() api.Processor {
@@ -24,6 +25,9 @@ func GetImageProcessor() api.Processor {
 }
 
 func (r imageProcessor) Process(e *event.Msg) error {
+	if !r.ShouldProcess(e) {
+		return nil
+	}
 	c, err := dao.GetCaseByEvent(e)
 	if err != nil {
 		return err
@@ -43,6 +47,6 @@ func (r imageProcessor) Process(e *event.Msg) error {
 		return err
 	}
 
-	dao.SendMsg(c.ChannelID, c.UserID, config.Conf.Ack)
+	dao.SendMsg(c.ChannelID, c.UserID, i18n.T(dao.CaseLocale(c), "comment.ack"))
 	return nil
 }
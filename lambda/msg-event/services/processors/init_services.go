@@ -1,6 +1,10 @@
 package processors
 
 import (
+	"fmt"
+	"msg-event/config"
+	"msg-event/dao"
+	"msg-event/model/event"
 	"msg-event/services/api"
 	"msg-event/services/handlers"
 )
@@ -8,24 +12,70 @@ import (
 var serverManager map[string]api.Server
 var defaultKey = "default"
 
+// servers are the commands in the order of the help card.
+var servers []api.Server
+
+// InitServices registers every server under the name and the aliases it
+// describes, the server without a name handles the other messages.
 func InitServices() {
+	servers = []api.Server{
+		handlers.GetOpenCaseServ(),
+		handlers.GetCaseFormServ(),
+		handlers.GetTitleServ(),
+		handlers.GetContentServ(),
+		handlers.GetAccountServ(),
+		handlers.GetIssueTypeServ(),
+		handlers.GetServiceServ(),
+		handlers.GetCategoryServ(),
+		handlers.GetServ(),
+		handlers.GetQuotaServ(),
+		handlers.GetRegionServ(),
+		handlers.GetRequestedValueServ(),
+		handlers.GetLanguageServ(),
+		handlers.GetCCServ(),
+		handlers.GetLocaleServ(),
+		handlers.GetResolveServ(),
+		handlers.GetConfirmServ(),
+		handlers.GetApprovalServ(),
+		handlers.Gethelper(func() []api.Server { return servers }),
+		handlers.GetSearcher(),
+		handlers.GetQService(),
+		handlers.GetWhistlist(),
+		handlers.GetWhitelistDel(),
+		handlers.GetWhitelistCat(),
+		handlers.GetWhitelistImport(),
+		handlers.GetWhitelistExport(),
+		handlers.GetAdminWhitelist(),
+		handlers.GetRoleServ(),
+	}
 	serverManager = map[string]api.Server{
-		"开工单":         handlers.GetOpenCaseServ(),
-		"SUBJECT":     handlers.GetOpenCaseServ(),
-		"内容":          handlers.GetContentServ(),
-		"DESCRIPTION": handlers.GetContentServ(),
-		"账户":          handlers.GetAccountServ(),
-		"问题":          handlers.GetTitleServ(),
-		"响应速度":        handlers.GetServ(),
-		"服务":          handlers.GetServiceServ(),
-		"帮助":          handlers.Gethelper(),
-		"HELP":        handlers.Gethelper(),
-		"历史":          handlers.GetSearcher(),
-		"添加白名单":       handlers.GetWhistlist(),
-		"删除白名单":       handlers.GetWhitelistDel(),
-		"查看白名单":       handlers.GetWhitelistCat(),
-		"设置管理员":       handlers.GetAdminWhitelist(),
-		"Q":           handlers.GetQService(),
-		defaultKey:    handlers.GetCommentsServServ(),
+		defaultKey: handlers.GetCommentsServServ(),
+	}
+	for _, s := range servers {
+		c := s.Describe()
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			if _, ok := serverManager[name]; ok || name == "" {
+				panic(fmt.Sprintf("command %q of %T is empty or registered twice", name, s))
+			}
+			serverManager[name] = s
+		}
+	}
+}
+
+// dispatch runs the server once the operator has the role it requires.
+func dispatch(e *event.Msg, s api.Server, str string) (*dao.Case, error) {
+	if !permitted(e, s.RequiredRole()) {
+		return nil, nil
+	}
+	return s.Handle(e, str)
+}
+
+// permitted checks the role of the operator, a denied operator is answered
+// with the no permission card.
+func permitted(e *event.Msg, required config.Role) bool {
+	if err := dao.CheckPermission(e.Operator(), required); err != nil {
+		dao.SendNoPermissionCard(e.ChatID(), e.Operator(), required)
+		return false
 	}
+	return true
 }
@@ -1,11 +1,14 @@
 package processors
 
 import (
+	"errors"
 	"msg-event/dao"
 	"msg-event/model/event"
 	"msg-event/services/api"
+	"msg-event/services/handlers"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/sirupsen/logrus"
 )
 
@@ -39,8 +42,16 @@ func RefreshComments() error {
 	}
 	// for loop get latest comments
 
+	refreshed := make([]*dao.Case, 0, len(cs))
+	var changed []*dao.Case
 	for _, c := range cs {
 		comments, err := dao.GetCaseComments(c, c.LastCommentTime)
+		var accErr *dao.AccountError
+		if errors.As(err, &accErr) {
+			// one misconfigured account should not stop the others
+			logrus.Warnf("skip case %s, %v", c.CaseID, err)
+			continue
+		}
 		if err != nil {
 			logrus.Errorf("failed to get all comments %s", err)
 			return err
@@ -50,12 +61,13 @@ func RefreshComments() error {
 			logrus.Errorf("failed to get aws case %s", err)
 			return err
 		}
-		if *awscase.Cases[0].Status == "resolved" {
-			c.Status = dao.STATUS_CLOSE
+		if c.SetAWSStatus(aws.ToString(awscase.Cases[0].Status)) {
+			changed = append(changed, c)
 		}
 		c.Comments = comments
+		refreshed = append(refreshed, c)
 	}
-	for _, c := range cs {
+	for _, c := range refreshed {
 		c.LastCommentTime = time.Now()
 		_, err := dao.UpsertCase(c)
 		if err != nil {
@@ -63,11 +75,16 @@ func RefreshComments() error {
 			return err
 		}
 		// send all comments to channel
-		_, err = dao.SendMsg(c.ChannelID, c.UserID, dao.FormatComments(c.Comments))
+		_, err = dao.SendMsg(c.ChannelID, c.UserID, dao.FormatComments(dao.CaseLocale(c), c.Comments))
 		if err != nil {
 			logrus.Errorf("failed to send comments %s", err)
 			return err
 		}
 	}
+	for _, c := range changed {
+		if err := handlers.NotifyStatusChange(c); err != nil {
+			logrus.Errorf("failed to send status of case %s, %v", c.CaseID, err)
+		}
+	}
 	return nil
 }
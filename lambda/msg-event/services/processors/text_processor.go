@@ -2,13 +2,11 @@ package processors
 
 import (
 	"encoding/json"
-	"msg-event/config"
 	"msg-event/dao"
 	"msg-event/model"
 	"msg-event/model/event"
 	"msg-event/services/api"
-	"os"
-	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -20,16 +18,9 @@ func GetTextProcessor() api.Processor {
 	return &textProcessor{}
 }
 
+// ShouldProcess accepts every text, permissions are checked per command by
+// dispatch.
 func (r textProcessor) ShouldProcess(e *event.Msg) bool {
-	//perimission judgetment
-	userId := e.Event.Sender.SenderIDs.UserID
-	_, ok := config.Conf.UserWhiteListMap[userId]
-
-	if os.Getenv("ENABLE_USER_WHITELIST") == "true" && !ok {
-		fromChannelID := e.Event.Message.ChatID
-		dao.SendMsgToChannel(fromChannelID, config.Conf.NoPermissionMSG)
-		return false
-	}
 	return true
 }
 
@@ -43,21 +34,20 @@ func (r textProcessor) Process(e *event.Msg) (err error) {
 		if err = json.Unmarshal([]byte(e.Event.Message.Content), c); err != nil {
 			return err
 		}
-		tokens := strings.SplitN(strings.Trim(c.Text, " "), " ", 2)
-		cmd := tokens[0]
-		content := ""
-		if len(tokens) == 2 {
-			content = tokens[1]
-		}
+		// the case record is read once, and only when it matters
+		caseGroup := sync.OnceValue(func() bool { return inCaseGroup(e) })
+		cmd, content, text := parseMessage(c.Text, e.Event.Message.Mentions, caseGroup)
 		logrus.Infof("cmd %s, rest %s", cmd, content)
 
-		if v, ok := serverManager[cmd]; ok {
-			logrus.Infof("commond %s. content %s", cmd, content)
-			_, err = v.Handle(e, content)
+		if name, ok := lookupCommand(cmd); ok {
+			logrus.Infof("commond %s. content %s", name, content)
+			_, err = dispatch(e, serverManager[name], content)
+		} else if suggestions := suggestCommand(cmd); len(suggestions) > 0 && !caseGroup() {
+			logrus.Infof("unknown command %s, suggest %v", cmd, suggestions)
+			_, err = dao.SendMsgToChannel(e.Event.Message.ChatID, suggestionMsg(dao.GetLocale(e.ChatID(), e.Operator()), cmd, suggestions))
 		} else {
-			logrus.Infof("default as case comment %s", c.Text)
-			v = serverManager[defaultKey]
-			_, err = v.Handle(e, c.Text)
+			logrus.Infof("default as case comment %s", text)
+			_, err = dispatch(e, serverManager[defaultKey], text)
 		}
 		if err != nil {
 			logrus.Errorf("process case failed %v", err)
@@ -67,3 +57,10 @@ func (r textProcessor) Process(e *event.Msg) (err error) {
 	}
 	return err
 }
+
+// inCaseGroup reports whether the chat is the group of a case, chats
+// without a case record get an error and are not.
+func inCaseGroup(e *event.Msg) bool {
+	c, err := dao.GetCase(e.ChatID())
+	return err == nil && c != nil && c.Type == dao.TYPE_CASE
+}
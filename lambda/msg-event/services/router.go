@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"msg-event/dao"
+	"msg-event/i18n"
+	"msg-event/model/event"
+	"msg-event/model/response"
+	"msg-event/services/processors"
+
+	"github.com/sirupsen/logrus"
+)
+
+type route func(ctx context.Context, e *event.Envelope, resp *response.MsgResponse) error
+
+// eventRouter picks the route by header.event_type
+var eventRouter map[string]route
+
+func InitRouter() {
+	eventRouter = map[string]route{
+		event.TypeURLVerification: routeURLVerification,
+		event.TypeMessageReceive:  routeMessage,
+		event.TypeCardAction:      routeCardAction,
+		event.TypeBotAdded:        routeBotAdded,
+		event.TypeMessageRecalled: routeMessageRecalled,
+		event.TypeFreshComment:    routeFreshComment,
+		event.TypeSyncAccounts:    routeSyncAccounts,
+	}
+}
+
+func routeURLVerification(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	logrus.Infof("Return challenge for url_verification")
+	return nil
+}
+
+func routeMessage(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	payload := &event.Event{}
+	if err := e.Decode(payload); err != nil {
+		return err
+	}
+	msg := &event.Msg{
+		Schema: e.Schema,
+		Header: e.Header,
+		Event:  *payload,
+	}
+
+	if !Processable(msg) {
+		logrus.Infof("Duplicate message with same eventID")
+		return nil
+	}
+	p, ok := processorManager[payload.Message.MsgType]
+	if !ok {
+		logrus.Warnf("ignore unsupported message type %s", payload.Message.MsgType)
+		return nil
+	}
+	if err := p.Process(msg); err != nil {
+		return err
+	}
+
+	caze, err := renderCase(msg)
+	if err != nil || caze == nil {
+		return err
+	}
+	resp.Elements = caze.CardMsg.Card.Elements
+	return nil
+}
+
+func routeCardAction(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	payload, err := e.CardAction()
+	if err != nil {
+		return err
+	}
+	if payload.Action == nil || payload.Action.Value == nil {
+		logrus.Warnf("ignore card action without value %+v", payload)
+		return nil
+	}
+	msg := &event.Msg{
+		Schema:     e.Schema,
+		Header:     e.Header,
+		OpenID:     payload.Operator.OpenID,
+		UserID:     payload.Operator.UserID,
+		TenantKey:  payload.Operator.TenantKey,
+		OpenMsgID:  payload.Context.OpenMsgID,
+		OpenChatID: payload.Context.OpenChatID,
+		Token:      payload.Token,
+		Action:     payload.Action,
+	}
+
+	if err = processors.GetCardProcessor().Process(msg); err != nil {
+		return err
+	}
+	if payload.Action.Value.Case != "" || payload.Action.Value.Card != "" {
+		// the card is not the case card, the handler updates it
+		return nil
+	}
+
+	caze, err := renderCase(msg)
+	if err != nil || caze == nil {
+		return err
+	}
+	if e.Header.EventType == event.TypeCardAction {
+		resp.Card = &response.Card{
+			Type: "raw",
+			Data: &caze.CardMsg.Card,
+		}
+	} else {
+		resp.Elements = caze.CardMsg.Card.Elements
+	}
+	return nil
+}
+
+func routeBotAdded(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	payload := &event.BotAdded{}
+	if err := e.Decode(payload); err != nil {
+		return err
+	}
+	logrus.Infof("bot added to chat %s by %s", payload.ChatID, payload.OperatorID.UserID)
+	_, err := dao.SendTextToChannel(payload.ChatID, i18n.T(dao.GetLocale(payload.ChatID, payload.OperatorID.UserID), "usage"))
+	return err
+}
+
+func routeMessageRecalled(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	payload := &event.MessageRecalled{}
+	if err := e.Decode(payload); err != nil {
+		return err
+	}
+	logrus.Infof("message %s recalled in chat %s, type %s", payload.MsgID, payload.ChatID, payload.RecallType)
+	return nil
+}
+
+func routeFreshComment(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	return processors.GetRefreshCommentProcessor().Process(&event.Msg{
+		Schema: e.Schema,
+		Header: e.Header,
+	})
+}
+
+func routeSyncAccounts(_ context.Context, e *event.Envelope, resp *response.MsgResponse) error {
+	return processors.GetAccountSyncProcessor().Process(&event.Msg{
+		Schema: e.Schema,
+		Header: e.Header,
+	})
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"msg-event/dao"
+	"msg-event/model/event"
+	"net/http"
+	"strconv"
+	"time"
+
+	larkcard "github.com/larksuite/oapi-sdk-go/v3/card"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrVerifyFailed is returned when a request can not be proved to come from Lark.
+var ErrVerifyFailed = errors.New("lark request verification failed")
+
+// signatureMaxAge is how far the signed timestamp may be from now, a captured
+// request can not be replayed after it.
+const signatureMaxAge = 5 * time.Minute
+
+var now = time.Now
+
+// VerifyRequest checks the signature headers and the verification token of a
+// raw Lark callback, and returns the event decrypted when it was encrypted.
+func VerifyRequest(header http.Header, body []byte) (*event.Envelope, error) {
+	encryptKey, err := dao.GetEncryptKey()
+	if err != nil {
+		logrus.Errorf("failed to get encrypt key %v", err)
+		return nil, err
+	}
+	token, err := dao.GetVerificationToken()
+	if err != nil {
+		logrus.Errorf("failed to get verification token %v", err)
+		return nil, err
+	}
+	return verifyRequest(header, body, encryptKey, token)
+}
+
+func verifyRequest(header http.Header, body []byte, encryptKey, token string) (*event.Envelope, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%w: verification token is not configured", ErrVerifyFailed)
+	}
+
+	plain, err := decryptBody(body, encryptKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &event.Envelope{}
+	if err = json.Unmarshal(plain, e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerifyFailed, err)
+	}
+
+	// url_verification is never signed, the token is the only proof
+	if e.Type != event.TypeURLVerification {
+		if err = verifySignature(header, body, encryptKey, token); err != nil {
+			return nil, err
+		}
+	}
+
+	reqToken := e.Token
+	if e.Header.Token != "" {
+		reqToken = e.Header.Token
+	}
+	if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+		return nil, fmt.Errorf("%w: token mismatch", ErrVerifyFailed)
+	}
+	return e, nil
+}
+
+func decryptBody(body []byte, encryptKey string) ([]byte, error) {
+	encrypted := &event.Encrypted{}
+	if err := json.Unmarshal(body, encrypted); err != nil || encrypted.Encrypt == "" {
+		return body, nil
+	}
+	if encryptKey == "" {
+		return nil, fmt.Errorf("%w: encrypted event received but encrypt key is not configured", ErrVerifyFailed)
+	}
+	plain, err := larkevent.EventDecrypt(encrypted.Encrypt, encryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerifyFailed, err)
+	}
+	return plain, nil
+}
+
+// verifySignature accepts both the sha256 signature of event subscriptions,
+// which is keyed by the encrypt key, and the sha1 signature of card callbacks,
+// which is keyed by the verification token.
+func verifySignature(header http.Header, body []byte, encryptKey, token string) error {
+	timestamp := header.Get(larkevent.EventRequestTimestamp)
+	nonce := header.Get(larkevent.EventRequestNonce)
+	signature := header.Get(larkevent.EventSignature)
+
+	if signature == "" {
+		// Lark only signs events when an encrypt key is set
+		if encryptKey == "" {
+			return nil
+		}
+		return fmt.Errorf("%w: missing signature", ErrVerifyFailed)
+	}
+	if timestamp == "" || nonce == "" {
+		return fmt.Errorf("%w: missing timestamp or nonce", ErrVerifyFailed)
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %q", ErrVerifyFailed, timestamp)
+	}
+	if age := now().Sub(time.Unix(sec, 0)); age > signatureMaxAge || age < -signatureMaxAge {
+		return fmt.Errorf("%w: timestamp %s is out of the allowed window", ErrVerifyFailed, timestamp)
+	}
+
+	if encryptKey != "" && signatureEqual(larkevent.Signature(timestamp, nonce, encryptKey, string(body)), signature) {
+		return nil
+	}
+	if signatureEqual(larkcard.Signature(timestamp, nonce, token, string(body)), signature) {
+		return nil
+	}
+	return fmt.Errorf("%w: signature mismatch", ErrVerifyFailed)
+}
+
+func signatureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	larkcard "github.com/larksuite/oapi-sdk-go/v3/card"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+)
+
+const (
+	testEncryptKey = "encrypt-key"
+	testToken      = "verification-token"
+	testNonce      = "nonce"
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+// encrypt is the AES-256-CBC encryption of Lark events.
+func encrypt(t *testing.T, plain, key string) []byte {
+	t.Helper()
+	k := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	buf := append([]byte(plain), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	iv := bytes.Repeat([]byte{7}, aes.BlockSize)
+	out := make([]byte, len(buf))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, buf)
+	body, _ := json.Marshal(map[string]string{"encrypt": base64.StdEncoding.EncodeToString(append(iv, out...))})
+	return body
+}
+
+func signed(ts time.Time, signature func(ts, nonce, body string) string, body []byte) http.Header {
+	h := http.Header{}
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	h.Set(larkevent.EventRequestTimestamp, timestamp)
+	h.Set(larkevent.EventRequestNonce, testNonce)
+	h.Set(larkevent.EventSignature, signature(timestamp, testNonce, string(body)))
+	return h
+}
+
+func eventSignature(key string) func(ts, nonce, body string) string {
+	return func(ts, nonce, body string) string { return larkevent.Signature(ts, nonce, key, body) }
+}
+
+func cardSignature(token string) func(ts, nonce, body string) string {
+	return func(ts, nonce, body string) string { return larkcard.Signature(ts, nonce, token, body) }
+}
+
+func TestVerifyRequest(t *testing.T) {
+	now = func() time.Time { return testNow }
+	t.Cleanup(func() { now = time.Now })
+
+	v2Event := `{"schema":"2.0","header":{"event_type":"im.message.receive_v1","token":"` + testToken + `"},"event":{}}`
+	cardAction := `{"open_id":"ou_1","token":"` + testToken + `","action":{"value":{"key":"k"}}}`
+	challenge := `{"type":"url_verification","challenge":"c1","token":"` + testToken + `"}`
+	wrongToken := `{"schema":"2.0","header":{"event_type":"im.message.receive_v1","token":"other"},"event":{}}`
+	encrypted := encrypt(t, v2Event, testEncryptKey)
+
+	tests := []struct {
+		name       string
+		header     http.Header
+		body       []byte
+		encryptKey string
+		token      string
+		wantErr    bool
+	}{
+		{name: "event signed with the encrypt key", body: encrypted, encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow, eventSignature(testEncryptKey), encrypted)},
+		{name: "card signed with the token", body: []byte(cardAction), encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow, cardSignature(testToken), []byte(cardAction))},
+		{name: "unsigned without encrypt key", body: []byte(v2Event), token: testToken, header: http.Header{}},
+		{name: "url verification is not signed", body: []byte(challenge), encryptKey: testEncryptKey, token: testToken,
+			header: http.Header{}},
+		{name: "timestamp within the window", body: encrypted, encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow.Add(-4*time.Minute), eventSignature(testEncryptKey), encrypted)},
+
+		{name: "token not configured", body: []byte(v2Event), header: http.Header{}, wantErr: true},
+		{name: "token mismatch", body: []byte(wrongToken), token: testToken, header: http.Header{}, wantErr: true},
+		{name: "missing signature with encrypt key", body: encrypted, encryptKey: testEncryptKey, token: testToken,
+			header: http.Header{}, wantErr: true},
+		{name: "signed with another key", body: encrypted, encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow, eventSignature("other"), encrypted), wantErr: true},
+		{name: "body changed after signing", body: []byte(cardAction), encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow, cardSignature(testToken), []byte(challenge)), wantErr: true},
+		{name: "stale timestamp", body: encrypted, encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow.Add(-6*time.Minute), eventSignature(testEncryptKey), encrypted), wantErr: true},
+		{name: "timestamp in the future", body: encrypted, encryptKey: testEncryptKey, token: testToken,
+			header: signed(testNow.Add(6*time.Minute), eventSignature(testEncryptKey), encrypted), wantErr: true},
+		{name: "encrypted without encrypt key", body: encrypted, token: testToken, header: http.Header{}, wantErr: true},
+		{name: "encrypted with another key", body: encrypt(t, v2Event, "other"), encryptKey: testEncryptKey, token: testToken,
+			header: http.Header{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := verifyRequest(tt.header, tt.body, tt.encryptKey, tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrVerifyFailed) {
+					t.Errorf("got %v, want ErrVerifyFailed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rejected: %v", err)
+			}
+			if e == nil {
+				t.Fatal("no envelope")
+			}
+		})
+	}
+}
+
+func TestVerifySignatureTimestamp(t *testing.T) {
+	now = func() time.Time { return testNow }
+	t.Cleanup(func() { now = time.Now })
+
+	body := []byte(`{}`)
+	header := func(timestamp string) http.Header {
+		h := http.Header{}
+		h.Set(larkevent.EventRequestTimestamp, timestamp)
+		h.Set(larkevent.EventRequestNonce, testNonce)
+		h.Set(larkevent.EventSignature, larkevent.Signature(timestamp, testNonce, testEncryptKey, string(body)))
+		return h
+	}
+	tests := []struct {
+		name      string
+		timestamp string
+		wantErr   bool
+	}{
+		{name: "now", timestamp: strconv.FormatInt(testNow.Unix(), 10)},
+		{name: "at the edge", timestamp: strconv.FormatInt(testNow.Add(-signatureMaxAge).Unix(), 10)},
+		{name: "past the edge", timestamp: strconv.FormatInt(testNow.Add(-signatureMaxAge-time.Second).Unix(), 10), wantErr: true},
+		{name: "milliseconds", timestamp: strconv.FormatInt(testNow.UnixMilli(), 10), wantErr: true},
+		{name: "not a number", timestamp: "yesterday", wantErr: true},
+		{name: "empty", timestamp: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(header(tt.timestamp), body, testEncryptKey, testToken)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
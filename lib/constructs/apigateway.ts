@@ -14,41 +14,13 @@ export class ApiGateway {
 
     const eventMessages = msgEventApi.root.addResource('messages');
 
+    // Proxy integration passes the raw body and the X-Lark-* headers through,
+    // both are needed to verify the signature of the request.
     eventMessages.addMethod(
       'POST',
       new apigateway.LambdaIntegration(msgEventAlias, {
-        proxy: false,
-        integrationResponses: [
-          {
-            statusCode: '200',
-            responseTemplates: {
-              'application/json': '',
-            }
-          },
-        ],
-      }),
-      {
-        methodResponses: [
-          {
-            statusCode: "200",
-            responseModels: {
-              "application/json": apigateway.Model.EMPTY_MODEL
-            }
-          },
-          {
-            statusCode: "400",
-            responseModels: {
-              "application/json": apigateway.Model.ERROR_MODEL
-            }
-          },
-          {
-            statusCode: "500",
-            responseModels: {
-              "application/json": apigateway.Model.ERROR_MODEL
-            }
-          }
-        ]
-      }
+        proxy: true,
+      })
     );
   }
-}
\ No newline at end of file
+}
@@ -7,7 +7,7 @@ import { Construct } from 'constructs';
 export class EventBridgeBusAndRules {
   public larkbotCaseEventBus: events.EventBus;
 
-  constructor(scope: Construct, msgEventAlias: lambda.Alias, refreshInterval: cdk.CfnParameter) {
+  constructor(scope: Construct, msgEventAlias: lambda.Alias, refreshInterval: cdk.CfnParameter, accountSyncInterval: cdk.CfnParameter) {
     // Create a new EventBus
     this.larkbotCaseEventBus = new events.EventBus(scope, 'larkbot-case-event-bus', {
     });
@@ -27,10 +27,8 @@ export class EventBridgeBusAndRules {
     larkbotCaseEventRule.addTarget(new targets.LambdaFunction(msgEventAlias, {
       event: events.RuleTargetInput.fromObject({
         schema: "2.0",
-        event: {
-          message: {
-            message_type: "fresh_comment"
-          }
+        header: {
+          event_type: "fresh_comment"
         }
       })
     }));
@@ -57,12 +55,25 @@ export class EventBridgeBusAndRules {
     refreshEventRule.addTarget(new targets.LambdaFunction(msgEventAlias, {
       event: events.RuleTargetInput.fromObject({
         schema: "2.0",
-        event: {
-          message: {
-            message_type: "fresh_comment"
-          }
+        header: {
+          event_type: "fresh_comment"
+        }
+      })
+    }));
+
+    // Sync accounts from AWS Organizations, skipped unless account_sync is enabled in the bot config
+    const accountSyncRule = new events.Rule(scope, 'accountSyncRule', {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(accountSyncInterval.valueAsNumber)),
+      description: `Sync accounts from AWS Organizations every ${accountSyncInterval.valueAsString} minutes`,
+    });
+
+    accountSyncRule.addTarget(new targets.LambdaFunction(msgEventAlias, {
+      event: events.RuleTargetInput.fromObject({
+        schema: "2.0",
+        header: {
+          event_type: "sync_accounts"
         }
       })
     }));
   }
-}
\ No newline at end of file
+}
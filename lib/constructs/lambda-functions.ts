@@ -16,7 +16,7 @@ export class LambdaFunctions {
     scope: Construct,
     dynamoDBTables: { auditTable: dynamodb.Table; botCasesTable: dynamodb.Table; botConfigTable: dynamodb.Table },
     sqsQueues: { qContentQ: sqs.Queue },
-    secrets: { AppIDSecret: secretsmanager.Secret; AppSecretSecret: secretsmanager.Secret },
+    secrets: { AppIDSecret: secretsmanager.Secret; AppSecretSecret: secretsmanager.Secret; EncryptKeySecret: secretsmanager.Secret; VerificationTokenSecret: secretsmanager.Secret },
     params: { configKey: cdk.CfnParameter; caseLanguage: cdk.CfnParameter; userWhitelist: cdk.CfnParameter; supportRegion: cdk.CfnParameter; botEndpoint: cdk.CfnParameter }
   ) {
     // Define msgEvent handler
@@ -60,9 +60,11 @@ export class LambdaFunctions {
       version: msgEventVersion,
     });
 
-    // Grant the RO access of AppID and AppSecret to msgEvent function
+    // Grant the RO access of AppID, AppSecret, EncryptKey and VerificationToken to msgEvent function
     secrets.AppIDSecret.grantRead(this.msgEventAlias);
     secrets.AppSecretSecret.grantRead(this.msgEventAlias);
+    secrets.EncryptKeySecret.grantRead(this.msgEventAlias);
+    secrets.VerificationTokenSecret.grantRead(this.msgEventAlias);
 
     // Attach the policy document that allow to assume the support role in others accounts to the lambda function's role
     this.msgEventAlias.addToRolePolicy(new iam.PolicyStatement({
@@ -72,6 +74,21 @@ export class LambdaFunctions {
       resources: ['arn:aws:iam::*:role/FeishuSupportCaseApiAll*']
     }));
 
+    // Allow to list the organization accounts, directly or through a role in the management account
+    this.msgEventAlias.addToRolePolicy(new iam.PolicyStatement({
+      sid: 'AllowToListOrganizationAccounts',
+      effect: iam.Effect.ALLOW,
+      actions: ['organizations:ListAccounts'],
+      resources: ['*']
+    }));
+
+    this.msgEventAlias.addToRolePolicy(new iam.PolicyStatement({
+      sid: 'AllowToAssumeToOrganizationReadRole',
+      effect: iam.Effect.ALLOW,
+      actions: ['sts:AssumeRole'],
+      resources: ['arn:aws:iam::*:role/FeishuSupportCaseOrgRead*']
+    }));
+
     // Grant RW access of ddb tables to msgEvent function 
     dynamoDBTables.auditTable.grantReadWriteData(this.msgEventAlias);
     dynamoDBTables.botCasesTable.grantReadWriteData(this.msgEventAlias);
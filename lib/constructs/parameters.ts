@@ -4,11 +4,14 @@ import { Construct } from 'constructs';
 export class CfnParameters {
   public readonly appID: cdk.CfnParameter;
   public readonly appSecret: cdk.CfnParameter;
+  public readonly encryptKey: cdk.CfnParameter;
+  public readonly verificationToken: cdk.CfnParameter;
   public readonly caseLanguage: cdk.CfnParameter;
   public readonly configKey: cdk.CfnParameter;
   public readonly userWhitelist: cdk.CfnParameter;
   public readonly supportRegion: cdk.CfnParameter;
   public readonly refreshInterval: cdk.CfnParameter;
+  public readonly accountSyncInterval: cdk.CfnParameter;
   public readonly botEndpoint: cdk.CfnParameter;
 
   constructor(scope: Construct) {
@@ -26,6 +29,20 @@ export class CfnParameters {
       default: 'XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX',
     });
 
+    this.encryptKey = new cdk.CfnParameter(scope, 'EncryptKey', {
+      type: 'String',
+      description: 'The Encrypt Key of larkbot event subscription',
+      noEcho: true,
+      default: 'XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX',
+    });
+
+    this.verificationToken = new cdk.CfnParameter(scope, 'VerificationToken', {
+      type: 'String',
+      description: 'The Verification Token of larkbot event subscription',
+      noEcho: true,
+      default: 'XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX',
+    });
+
     this.caseLanguage = new cdk.CfnParameter(scope, 'CaseLanguage', {
       type: 'String',
       description: 'Case Language queue. Should be in "zh", "ja", "ko", "en"',
@@ -64,6 +81,13 @@ export class CfnParameters {
       default: 10
     });
 
+    this.accountSyncInterval = new cdk.CfnParameter(scope, 'AccountSyncInterval', {
+      type: 'Number',
+      description: 'AWS Organizations account sync interval (in minutes)',
+      noEcho: false,
+      default: 1440
+    });
+
     this.botEndpoint = new cdk.CfnParameter(scope, 'LarkEndpoint', {
       type: 'String',
       description: 'Lark endpoint',
@@ -9,6 +9,8 @@ import * as secretsmanager from 'aws-cdk-lib/aws-secretsmanager';
 export class Secrets {
   public readonly AppIDSecret: secretsmanager.Secret;
   public readonly AppSecretSecret: secretsmanager.Secret;
+  public readonly EncryptKeySecret: secretsmanager.Secret;
+  public readonly VerificationTokenSecret: secretsmanager.Secret;
 
   constructor(scope: Construct, params: CfnParameters) {
     this.AppIDSecret = new secretsmanager.Secret(scope, 'AppIDSecret', {
@@ -20,5 +22,15 @@ export class Secrets {
       description: 'The Secret to store the value of App Secret',
       secretStringValue: cdk.SecretValue.cfnParameter(params.appSecret),
     });
+
+    this.EncryptKeySecret = new secretsmanager.Secret(scope, 'EncryptKeySecret', {
+      description: 'The Secret to store the value of Encrypt Key',
+      secretStringValue: cdk.SecretValue.cfnParameter(params.encryptKey),
+    });
+
+    this.VerificationTokenSecret = new secretsmanager.Secret(scope, 'VerificationTokenSecret', {
+      description: 'The Secret to store the value of Verification Token',
+      secretStringValue: cdk.SecretValue.cfnParameter(params.verificationToken),
+    });
   }
 }
\ No newline at end of file
@@ -18,7 +18,7 @@ export class LarkAwsKnowledgeAssistantStack extends cdk.Stack {
     const sqsQueues = new SQSQueues(this);
     const lambdaFunctions = new LambdaFunctions(this, dynamoDBTables, sqsQueues, secrets, parameters);
     new ApiGateway(this, lambdaFunctions.msgEventAlias);
-    const eventBridgeBusAndRules = new EventBridgeBusAndRules(this, lambdaFunctions.msgEventAlias, parameters.refreshInterval);
+    const eventBridgeBusAndRules = new EventBridgeBusAndRules(this, lambdaFunctions.msgEventAlias, parameters.refreshInterval, parameters.accountSyncInterval);
 
     // Output the arn of the msgEventRole
     new cdk.CfnOutput(this,'msgEventRoleArn', {